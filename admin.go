@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireAdmin only lets requests through that carry the ADMIN_TOKEN from the
+// environment in the X-Admin-Token header. Without a configured token every
+// admin-only route is closed.
+func requireAdmin(c *fiber.Ctx) error {
+	if !isAdminToken(c.Get("X-Admin-Token")) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin access required",
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Bu işlem için yönetici yetkisi gereklidir.",
+		})
+	}
+	return c.Next()
+}
+
+// isAdminToken reports whether given is the configured ADMIN_TOKEN.
+func isAdminToken(given string) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(given)) == 1
+}
+
+// adminName is who made an admin decision. All admins share ADMIN_TOKEN, so
+// the admin page sends the name, URL-encoded, in the X-Admin-Name header.
+func adminName(c *fiber.Ctx) string {
+	name, err := url.QueryUnescape(c.Get("X-Admin-Name"))
+	if err != nil || strings.TrimSpace(name) == "" {
+		return "admin"
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Break struct {
+	Start time.Time  `json:"start" bson:"start"`
+	End   *time.Time `json:"end,omitempty" bson:"end,omitempty"`
+}
+
+// Attendance is an employee's presence for one day, separate from the works
+// they log during it.
+type Attendance struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EmployeeID      primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	EmployeeName    string             `json:"employeeName" bson:"employeeName"`
+	Date            time.Time          `json:"date" bson:"date"`
+	CheckIn         time.Time          `json:"checkIn" bson:"checkIn"`
+	CheckOut        *time.Time         `json:"checkOut,omitempty" bson:"checkOut,omitempty"`
+	Breaks          []Break            `json:"breaks" bson:"breaks"`
+	PresenceMinutes int                `json:"presenceMinutes" bson:"presenceMinutes"` // Checked-in time minus breaks
+}
+
+type TimeInterval struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Minutes int       `json:"minutes"`
+}
+
+type AttendanceComparison struct {
+	Attendance         Attendance     `json:"attendance"`
+	PresenceMinutes    int            `json:"presenceMinutes"`
+	LoggedMinutes      int            `json:"loggedMinutes"`
+	UnaccountedMinutes int            `json:"unaccountedMinutes"`
+	Gaps               []TimeInterval `json:"gaps"` // Present, not on a break and no work running
+}
+
+func attendanceAction(c *fiber.Ctx) (primitive.ObjectID, error) {
+	var body struct {
+		EmployeeID primitive.ObjectID `json:"employeeId"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return body.EmployeeID, nil
+}
+
+// presenceMinutes is the time between check-in and check-out (or now) minus
+// the breaks taken.
+func presenceMinutes(attendance Attendance, now time.Time) int {
+	end := now
+	if attendance.CheckOut != nil {
+		end = *attendance.CheckOut
+	}
+	presence := end.Sub(attendance.CheckIn)
+	for _, b := range attendance.Breaks {
+		breakEnd := end
+		if b.End != nil {
+			breakEnd = *b.End
+		}
+		presence -= breakEnd.Sub(b.Start)
+	}
+	if presence < 0 {
+		return 0
+	}
+	return int(presence.Minutes())
+}
+
+func findTodaysAttendance(ctx context.Context, employeeID primitive.ObjectID) (Attendance, error) {
+	var attendance Attendance
+	err := db.Collection("attendance").FindOne(ctx, bson.M{
+		"employeeId": employeeID,
+		"date":       startOfDay(time.Now()),
+	}).Decode(&attendance)
+	return attendance, err
+}
+
+func checkIn(c *fiber.Ctx) error {
+	employeeID, err := attendanceAction(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name, ok := employeeName(ctx, employeeID)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Employee not found",
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Personel bulunamadı",
+		})
+	}
+
+	if _, err := findTodaysAttendance(ctx, employeeID); err != mongo.ErrNoDocuments {
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch attendance: " + err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Already checked in today",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bugün için zaten giriş yapılmış.",
+		})
+	}
+
+	now := time.Now()
+	attendance := Attendance{
+		ID:           primitive.NewObjectID(),
+		EmployeeID:   employeeID,
+		EmployeeName: name,
+		Date:         startOfDay(now),
+		CheckIn:      now,
+		Breaks:       []Break{},
+	}
+	if _, err := db.Collection("attendance").InsertOne(ctx, attendance); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check in: " + err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Giriş kaydedildi.",
+		"data":  attendance,
+	})
+}
+
+func checkOut(c *fiber.Ctx) error {
+	employeeID, err := attendanceAction(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attendance, err := findTodaysAttendance(ctx, employeeID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Not checked in today",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Bugün için giriş kaydı bulunmuyor.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch attendance: " + err.Error()})
+	}
+	if attendance.CheckOut != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Already checked out today",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bugün için zaten çıkış yapılmış.",
+		})
+	}
+
+	// An open break ends with the check-out
+	now := time.Now()
+	for i := range attendance.Breaks {
+		if attendance.Breaks[i].End == nil {
+			attendance.Breaks[i].End = &now
+		}
+	}
+	attendance.CheckOut = &now
+	attendance.PresenceMinutes = presenceMinutes(attendance, now)
+
+	_, err = db.Collection("attendance").UpdateOne(ctx, bson.M{"_id": attendance.ID}, bson.M{"$set": bson.M{
+		"checkOut":        now,
+		"breaks":          attendance.Breaks,
+		"presenceMinutes": attendance.PresenceMinutes,
+	}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check out: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Çıkış kaydedildi.",
+		"data":  attendance,
+	})
+}
+
+func startBreak(c *fiber.Ctx) error {
+	return updateBreak(c, true)
+}
+
+func endBreak(c *fiber.Ctx) error {
+	return updateBreak(c, false)
+}
+
+func updateBreak(c *fiber.Ctx, start bool) error {
+	employeeID, err := attendanceAction(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attendance, err := findTodaysAttendance(ctx, employeeID)
+	if err != nil || attendance.CheckOut != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No open attendance today",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bugün için açık bir giriş kaydı bulunmuyor.",
+		})
+	}
+
+	onBreak := len(attendance.Breaks) > 0 && attendance.Breaks[len(attendance.Breaks)-1].End == nil
+	now := time.Now()
+	if start {
+		if onBreak {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Break already started"})
+		}
+		attendance.Breaks = append(attendance.Breaks, Break{Start: now})
+	} else {
+		if !onBreak {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "No break in progress"})
+		}
+		attendance.Breaks[len(attendance.Breaks)-1].End = &now
+	}
+
+	_, err = db.Collection("attendance").UpdateOne(ctx, bson.M{"_id": attendance.ID}, bson.M{"$set": bson.M{"breaks": attendance.Breaks}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update break: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": attendance,
+	})
+}
+
+func getAttendance(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	filter := bson.M{"date": bson.M{"$gte": from, "$lte": to}}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter["employeeId"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("attendance").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch attendance: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	records := []Attendance{}
+	if err = cursor.All(ctx, &records); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode attendance: " + err.Error()})
+	}
+
+	now := time.Now()
+	for i := range records {
+		if records[i].CheckOut == nil {
+			records[i].PresenceMinutes = presenceMinutes(records[i], now)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": records,
+	})
+}
+
+// getAttendanceComparison compares an employee's presence on a day with the
+// works they logged, listing the present time not covered by any work.
+func getAttendanceComparison(c *fiber.Ctx) error {
+	employeeID, err := primitive.ObjectIDFromHex(c.Query("employeeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz personel ID formatı",
+		})
+	}
+
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	comparison, err := attendanceComparison(ctx, employeeID, date, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compare attendance: " + err.Error()})
+	}
+	if comparison == nil {
+		return c.JSON(fiber.Map{
+			"type":  "info",
+			"title": "Bilgi",
+			"text":  "Bu gün için giriş kaydı bulunmuyor.",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": comparison,
+	})
+}
+
+// attendanceComparison loads an employee's attendance on a day and the works
+// running during it. It returns nil when the employee did not check in.
+func attendanceComparison(ctx context.Context, employeeID primitive.ObjectID, date, now time.Time) (*AttendanceComparison, error) {
+	var attendance Attendance
+	err := db.Collection("attendance").FindOne(ctx, bson.M{"employeeId": employeeID, "date": date}).Decode(&attendance)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Without a check-out the day ends now, or at midnight for past days
+	dayEnd := minTime(now, date.AddDate(0, 0, 1))
+	if attendance.CheckOut != nil {
+		dayEnd = *attendance.CheckOut
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": employeeID,
+		"startTime":  bson.M{"$lt": dayEnd},
+		"status":     bson.M{"$nin": []string{"rejected", "pending_approval"}},
+		"deletedAt":  bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"endTime": bson.M{"$gt": attendance.CheckIn}},
+			{"endTime": bson.M{"$exists": false}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return nil, err
+	}
+
+	comparison := compareAttendance(attendance, works, dayEnd, now)
+	return &comparison, nil
+}
+
+// compareAttendance lists the present time between check-in and dayEnd that
+// is covered neither by a work nor by a break.
+func compareAttendance(attendance Attendance, works []Work, dayEnd, now time.Time) AttendanceComparison {
+	window := []timeRange{{Start: attendance.CheckIn, End: dayEnd}}
+
+	// Everything that accounts for present time: works and breaks
+	var covered []TimeInterval
+	var logged time.Duration
+	for _, work := range works {
+		// Only the part of a work inside the presence window counts
+		for _, active := range intersectIntervals(activeIntervals(work, now), window) {
+			covered = append(covered, TimeInterval{Start: active.Start, End: active.End})
+			if work.Status == "completed" {
+				logged += active.End.Sub(active.Start)
+			}
+		}
+	}
+	for _, b := range attendance.Breaks {
+		end := dayEnd
+		if b.End != nil {
+			end = *b.End
+		}
+		covered = append(covered, TimeInterval{Start: b.Start, End: end})
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].Start.Before(covered[j].Start) })
+
+	gaps := []TimeInterval{}
+	cursorTime := attendance.CheckIn
+	for _, interval := range covered {
+		if interval.Start.After(cursorTime) {
+			gapEnd := interval.Start
+			if gapEnd.After(dayEnd) {
+				gapEnd = dayEnd
+			}
+			if gapEnd.After(cursorTime) {
+				gaps = append(gaps, TimeInterval{Start: cursorTime, End: gapEnd})
+			}
+		}
+		if interval.End.After(cursorTime) {
+			cursorTime = interval.End
+		}
+	}
+	if dayEnd.After(cursorTime) {
+		gaps = append(gaps, TimeInterval{Start: cursorTime, End: dayEnd})
+	}
+
+	comparison := AttendanceComparison{
+		Attendance:      attendance,
+		PresenceMinutes: presenceMinutes(attendance, dayEnd),
+		LoggedMinutes:   int(logged.Minutes()),
+		Gaps:            []TimeInterval{},
+	}
+	for _, gap := range gaps {
+		gap.Minutes = int(gap.End.Sub(gap.Start).Minutes())
+		if gap.Minutes == 0 {
+			continue
+		}
+		comparison.UnaccountedMinutes += gap.Minutes
+		comparison.Gaps = append(comparison.Gaps, gap)
+	}
+	return comparison
+}
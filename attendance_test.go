@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareAttendance(t *testing.T) {
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	attendance := Attendance{
+		CheckIn:  at(9, 0),
+		CheckOut: ptr(at(17, 0)),
+		Breaks:   []Break{{Start: at(12, 0), End: ptr(at(13, 0))}},
+	}
+	works := []Work{
+		{StartTime: at(9, 30), EndTime: at(11, 30), Status: "completed"},
+		// Paused for half an hour, which counts as a gap
+		{StartTime: at(13, 0), EndTime: at(16, 0), Status: "completed", Pauses: []PauseInterval{{Start: at(14, 0), End: at(14, 30)}}},
+		// Started before check-in, only the part after it counts
+		{StartTime: at(8, 0), EndTime: at(9, 15), Status: "completed"},
+	}
+
+	got := compareAttendance(attendance, works, at(17, 0), at(18, 0))
+	wantGaps := []TimeInterval{
+		{Start: at(9, 15), End: at(9, 30), Minutes: 15},
+		{Start: at(11, 30), End: at(12, 0), Minutes: 30},
+		{Start: at(14, 0), End: at(14, 30), Minutes: 30},
+		{Start: at(16, 0), End: at(17, 0), Minutes: 60},
+	}
+	if !reflect.DeepEqual(got.Gaps, wantGaps) {
+		t.Errorf("Gaps = %v, want %v", got.Gaps, wantGaps)
+	}
+	if got.PresenceMinutes != 420 || got.LoggedMinutes != 285 || got.UnaccountedMinutes != 135 {
+		t.Errorf("presence, logged, unaccounted = %d, %d, %d, want 420, 285, 135",
+			got.PresenceMinutes, got.LoggedMinutes, got.UnaccountedMinutes)
+	}
+}
+
+func TestCompareAttendanceWithoutWorks(t *testing.T) {
+	checkIn := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	dayEnd := checkIn.Add(2 * time.Hour)
+
+	got := compareAttendance(Attendance{CheckIn: checkIn}, nil, dayEnd, dayEnd)
+	want := []TimeInterval{{Start: checkIn, End: dayEnd, Minutes: 120}}
+	if !reflect.DeepEqual(got.Gaps, want) {
+		t.Errorf("Gaps = %v, want %v", got.Gaps, want)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditEntry records one write request against the API. Entries are only
+// ever inserted, there is no route to change or remove them.
+type AuditEntry struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Actor      AuditActor         `json:"actor" bson:"actor"`
+	Action     string             `json:"action" bson:"action"` // "create", "update" or "delete"
+	Method     string             `json:"method" bson:"method"`
+	Route      string             `json:"route" bson:"route"` // Matched route, e.g. "/api/employees/:id"
+	Path       string             `json:"path" bson:"path"`
+	Status     int                `json:"status" bson:"status"`
+	TargetType string             `json:"targetType,omitempty" bson:"targetType,omitempty"` // Collection of the target
+	TargetID   primitive.ObjectID `json:"targetId,omitempty" bson:"targetId,omitempty"`
+	Before     bson.M             `json:"before,omitempty" bson:"before,omitempty"`
+	After      bson.M             `json:"after,omitempty" bson:"after,omitempty"`
+	IP         string             `json:"ip" bson:"ip"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// AuditActor is who sent the request. There are no user accounts, so admins
+// are recognised by the X-Admin-Token header and employees by the
+// X-Employee-Id header the employee page sends. Anyone can send that header,
+// so employee actors are never verified.
+type AuditActor struct {
+	Kind       string             `json:"kind" bson:"kind"`         // "admin", "employee" or "anonymous"
+	Verified   bool               `json:"verified" bson:"verified"` // Only admins prove who they are
+	EmployeeID primitive.ObjectID `json:"employeeId,omitempty" bson:"employeeId,omitempty"`
+	Name       string             `json:"name,omitempty" bson:"name,omitempty"`
+}
+
+// auditTargets maps the first path segment after /api to the collection the
+// route writes to.
+var auditTargets = map[string]string{
+	"employees":     "employees",
+	"teams":         "teams",
+	"internships":   "internships",
+	"leaves":        "leaves",
+	"holidays":      "holidays",
+	"attendance":    "attendance",
+	"work":          "works",
+	"tasks":         "tasks",
+	"webhooks":      "webhooks",
+	"chat-channels": "chat_channels",
+	"notifications": "notifications",
+}
+
+// auditRedacted fields are never copied into snapshots, at any depth. Keys
+// are compared in lower case.
+var auditRedacted = map[string]bool{"secret": true, "token": true, "password": true, "webhookurl": true}
+
+// auditWithoutSnapshots lists the "<resource>/<action>" routes whose entries
+// keep only the action and the target ID. Anonymising an employee scrubs the
+// audit log, so a snapshot of the employee taken by that request would put
+// their name and email straight back.
+var auditWithoutSnapshots = map[string]bool{"employees/anonymize": true}
+
+var auditActions = map[string]string{
+	fiber.MethodPost:   "create",
+	fiber.MethodPut:    "update",
+	fiber.MethodDelete: "delete",
+}
+
+// auditLog is used on the api group and writes an audit entry for every
+// POST, PUT and DELETE request after the handler ran.
+func auditLog(c *fiber.Ctx) error {
+	action, ok := auditActions[c.Method()]
+	if !ok {
+		return c.Next()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry := AuditEntry{
+		Actor:  auditActor(ctx, c),
+		Action: action,
+		Method: c.Method(),
+		Path:   c.Path(),
+		IP:     c.IP(),
+	}
+
+	snapshots := entry.setTarget(c.Path())
+	if snapshots && !entry.TargetID.IsZero() {
+		entry.Before = auditSnapshot(ctx, entry.TargetType, entry.TargetID)
+	}
+
+	handlerErr := c.Next()
+
+	entry.Route = c.Route().Path
+	entry.Status = c.Response().StatusCode()
+	if handlerErr != nil {
+		entry.Status = fiber.StatusInternalServerError
+		if fiberErr, ok := handlerErr.(*fiber.Error); ok {
+			entry.Status = fiberErr.Code
+		}
+	}
+	if entry.TargetID.IsZero() {
+		entry.TargetID = createdID(c.Response().Body())
+	}
+	if snapshots && !entry.TargetID.IsZero() && entry.Status < fiber.StatusBadRequest {
+		entry.After = auditSnapshot(ctx, entry.TargetType, entry.TargetID)
+	}
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	if _, err := db.Collection("audit_log").InsertOne(ctx, entry); err != nil {
+		log.Printf("Error writing audit entry for %s %s: %v", entry.Method, entry.Path, err)
+	}
+	return handlerErr
+}
+
+// setTarget fills in the target from the request path, since params are not
+// known before routing. It reports whether before/after snapshots of the
+// target may be kept.
+func (entry *AuditEntry) setTarget(path string) bool {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	entry.TargetType = auditTargets[segments[0]]
+	if len(segments) > 1 {
+		entry.TargetID, _ = primitive.ObjectIDFromHex(segments[1])
+	}
+	if len(segments) > 2 && auditWithoutSnapshots[segments[0]+"/"+segments[2]] {
+		return false
+	}
+	return entry.TargetType != ""
+}
+
+func auditActor(ctx context.Context, c *fiber.Ctx) AuditActor {
+	if isAdminToken(c.Get("X-Admin-Token")) {
+		return AuditActor{Kind: "admin", Verified: true, Name: adminName(c)}
+	}
+	if id, err := primitive.ObjectIDFromHex(c.Get("X-Employee-Id")); err == nil {
+		name, _ := employeeName(ctx, id)
+		return AuditActor{Kind: "employee", EmployeeID: id, Name: name}
+	}
+	return AuditActor{Kind: "anonymous"}
+}
+
+// auditSnapshot loads a document for the before/after fields, without the
+// redacted fields. A missing document gives nil.
+func auditSnapshot(ctx context.Context, collection string, id primitive.ObjectID) bson.M {
+	var doc bson.M
+	if err := db.Collection(collection).FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		return nil
+	}
+	redactAudit(doc)
+	return doc
+}
+
+// redactAudit replaces the values of auditRedacted fields in a document and
+// its embedded documents and arrays.
+func redactAudit(value interface{}) {
+	switch v := value.(type) {
+	case bson.M:
+		for key, item := range v {
+			if auditRedacted[strings.ToLower(key)] {
+				v[key] = "[redacted]"
+			} else {
+				redactAudit(item)
+			}
+		}
+	case primitive.A:
+		for _, item := range v {
+			redactAudit(item)
+		}
+	}
+}
+
+// createdID reads the ID of a created document from a handler response,
+// which is either the document itself or wrapped in "data".
+func createdID(body []byte) primitive.ObjectID {
+	var response struct {
+		ID   string `json:"id"`
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return primitive.NilObjectID
+	}
+	id := response.Data.ID
+	if id == "" {
+		id = response.ID
+	}
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	return objectID
+}
+
+// getAuditLog lists audit entries, newest first. Filters: actorId, actorKind,
+// action, method, route, targetType, targetId and from/to (YYYY-MM-DD).
+func getAuditLog(c *fiber.Ctx) error {
+	filter := bson.M{}
+	for param, field := range map[string]string{"actorId": "actor.employeeId", "targetId": "targetId"} {
+		if value := c.Query(param); value != "" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + param + " format"})
+			}
+			filter[field] = id
+		}
+	}
+	for param, field := range map[string]string{"actorKind": "actor.kind", "action": "action", "method": "method", "route": "route", "targetType": "targetType"} {
+		if value := c.Query(param); value != "" {
+			filter[field] = value
+		}
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		}
+		filter["createdAt"] = bson.M{"$gte": from, "$lte": to}
+	}
+	limit := int64(c.QueryInt("limit", 100))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+	skip := int64(c.QueryInt("skip", 0))
+	if skip < 0 {
+		skip = 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("audit_log").Find(ctx, filter,
+		options.Find().SetSort(bson.M{"createdAt": -1}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch audit log: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	entries := []AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode audit log: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": entries,
+	})
+}
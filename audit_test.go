@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreatedID(t *testing.T) {
+	id := primitive.ObjectID{1, 2, 3}
+
+	tests := []struct {
+		name string
+		body string
+		want primitive.ObjectID
+	}{
+		{"wrapped in data", `{"type":"success","data":{"id":"` + id.Hex() + `"}}`, id},
+		{"document itself", `{"id":"` + id.Hex() + `","name":"Ayşe"}`, id},
+		{"data wins over top level", `{"id":"` + primitive.NilObjectID.Hex() + `","data":{"id":"` + id.Hex() + `"}}`, id},
+		{"no ID", `{"message":"Work updated successfully"}`, primitive.NilObjectID},
+		{"invalid ID", `{"data":{"id":"abc"}}`, primitive.NilObjectID},
+		{"data is a list", `{"type":"success","data":[{"id":"` + id.Hex() + `"}]}`, primitive.NilObjectID},
+		{"not JSON", `PDF-1.4`, primitive.NilObjectID},
+		{"empty body", ``, primitive.NilObjectID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createdID([]byte(tt.body)); got != tt.want {
+				t.Errorf("createdID(%s) = %s, want %s", tt.body, got.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestRedactAudit(t *testing.T) {
+	doc := bson.M{
+		"name":       "Tasarım kanalı",
+		"webhookUrl": "https://hooks.slack.com/services/T000/B000/XXXX",
+		"Secret":     "s3cret",
+		"settings": bson.M{
+			"token": "abc",
+			"label": "kalsın",
+		},
+		"feeds": primitive.A{
+			bson.M{"token": "def", "employeeId": "1"},
+			"plain",
+		},
+	}
+	want := bson.M{
+		"name":       "Tasarım kanalı",
+		"webhookUrl": "[redacted]",
+		"Secret":     "[redacted]",
+		"settings": bson.M{
+			"token": "[redacted]",
+			"label": "kalsın",
+		},
+		"feeds": primitive.A{
+			bson.M{"token": "[redacted]", "employeeId": "1"},
+			"plain",
+		},
+	}
+
+	redactAudit(doc)
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("redactAudit() = %v, want %v", doc, want)
+	}
+}
+
+func TestAuditSetTarget(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		path          string
+		wantType      string
+		wantID        primitive.ObjectID
+		wantSnapshots bool
+	}{
+		{"/api/employees", "employees", primitive.NilObjectID, true},
+		{"/api/employees/" + id.Hex(), "employees", id, true},
+		{"/api/employees/" + id.Hex() + "/anonymize", "employees", id, false},
+		{"/api/work/" + id.Hex() + "/pause", "works", id, true},
+		{"/api/backup", "", primitive.NilObjectID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var entry AuditEntry
+			snapshots := entry.setTarget(tt.path)
+			if entry.TargetType != tt.wantType || entry.TargetID != tt.wantID || snapshots != tt.wantSnapshots {
+				t.Errorf("setTarget(%q) = %q, %s, %v, want %q, %s, %v", tt.path,
+					entry.TargetType, entry.TargetID.Hex(), snapshots, tt.wantType, tt.wantID.Hex(), tt.wantSnapshots)
+			}
+		})
+	}
+}
+
+func TestAnonymizeAuditEntryKeepsNoPersonalData(t *testing.T) {
+	id := primitive.NewObjectID()
+	employee := bson.M{"_id": id, "name": "Ayşe Yılmaz", "email": "ayse.yilmaz@example.com"}
+
+	// The same steps auditLog takes, with the employee as it is before the scrub
+	entry := AuditEntry{
+		Actor:  AuditActor{Kind: "admin", Verified: true, Name: "Yönetici"},
+		Action: "create",
+		Method: "POST",
+		Route:  "/api/employees/:id/anonymize",
+		Path:   "/api/employees/" + id.Hex() + "/anonymize",
+		Status: 200,
+	}
+	if entry.setTarget(entry.Path) {
+		entry.Before = employee
+		entry.After = employee
+	}
+
+	raw, err := bson.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"Ayşe", "Yılmaz", "ayse.yilmaz@example.com"} {
+		if bytes.Contains(raw, []byte(value)) {
+			t.Errorf("audit entry for the anonymize request contains %q", value)
+		}
+	}
+	if entry.TargetType != "employees" || entry.TargetID != id {
+		t.Errorf("target = %q, %s, want employees, %s", entry.TargetType, entry.TargetID.Hex(), id.Hex())
+	}
+}
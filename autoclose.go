@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// autoCloseCheckInterval is how often forgotten works are looked for.
+const autoCloseCheckInterval = time.Hour
+
+// autoCloseAfter reads WORK_AUTO_CLOSE_HOURS, the time after its start a
+// work that is still open gets closed. The default is 12 hours.
+func autoCloseAfter() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("WORK_AUTO_CLOSE_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 12
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// autoCloseEnd is the end time given to a forgotten work: where it was paused,
+// or the start plus the limit when it was left running.
+func autoCloseEnd(work Work, limit time.Duration) time.Time {
+	end := work.StartTime.Add(limit)
+	if work.PausedAt != nil && work.PausedAt.Before(end) {
+		return maxTime(*work.PausedAt, work.StartTime)
+	}
+	return end
+}
+
+// closeStaleWorks completes the works that have been open for longer than the
+// limit and marks them with autoClosedAt, so they show up in the digest.
+func closeStaleWorks(ctx context.Context, now time.Time, limit time.Duration) (int, error) {
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"status":    bson.M{"$in": []string{"in_progress", "paused"}},
+		"startTime": bson.M{"$lte": now.Add(-limit)},
+		"deletedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, work := range works {
+		end := autoCloseEnd(work, limit)
+		pausedMinutes := pausedMinutesUntil(work, end)
+		duration := end.Sub(work.StartTime) - time.Duration(pausedMinutes)*time.Minute
+		if duration < 0 {
+			duration = 0
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"status":          "completed",
+				"endTime":         end,
+				"duration":        duration.String(),
+				"durationMinutes": int(duration.Minutes()),
+				"pausedMinutes":   pausedMinutes,
+				"autoClosedAt":    now,
+			},
+			"$unset": bson.M{"pausedAt": ""},
+		}
+		if work.PausedAt != nil && end.After(*work.PausedAt) {
+			update["$push"] = bson.M{"pauses": PauseInterval{Start: *work.PausedAt, End: end}}
+		}
+
+		// The status filter skips works finished since they were read
+		result, err := db.Collection("works").UpdateOne(ctx, bson.M{"_id": work.ID, "status": work.Status}, update)
+		if err != nil {
+			return closed, err
+		}
+		closed += int(result.ModifiedCount)
+	}
+	return closed, nil
+}
+
+// runAutoCloseWorks closes forgotten works every autoCloseCheckInterval.
+func runAutoCloseWorks() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		closed, err := closeStaleWorks(ctx, time.Now(), autoCloseAfter())
+		cancel()
+		if err != nil {
+			log.Printf("Error closing forgotten works: %v", err)
+		} else if closed > 0 {
+			log.Printf("Closed %d forgotten works", closed)
+		}
+		time.Sleep(autoCloseCheckInterval)
+	}
+}
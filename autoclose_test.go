@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoCloseEnd(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { v := start.Add(d); return &v }
+
+	tests := []struct {
+		name     string
+		pausedAt *time.Time
+		want     time.Time
+	}{
+		{"left running", nil, start.Add(12 * time.Hour)},
+		{"paused before the limit", at(3 * time.Hour), start.Add(3 * time.Hour)},
+		{"paused after the limit", at(14 * time.Hour), start.Add(12 * time.Hour)},
+		{"paused before the start", at(-time.Hour), start},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			work := Work{StartTime: start, PausedAt: tt.pausedAt}
+			if got := autoCloseEnd(work, 12*time.Hour); !got.Equal(tt.want) {
+				t.Errorf("autoCloseEnd() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Backups are gzip compressed JSON lines: a header, one line per document
+// and a footer with the document counts. Documents are stored as canonical
+// extended JSON so ObjectIDs, dates and references such as reviewedVideoId
+// survive a restore unchanged.
+const (
+	backupFormat        = "work-tracking-system-backup"
+	backupFormatVersion = 1
+	backupBatchSize     = 500
+)
+
+// backupSkipped are collections tied to the cluster the data lives on: change
+// stream resume tokens and the events already handled from that stream. A
+// restored deployment starts its change streams afresh.
+var backupSkipped = map[string]bool{"event_stream_positions": true, "handled_events": true}
+
+// backupCollections sorts the collection names and leaves out system and
+// skipped collections.
+func backupCollections(names []string) []string {
+	collections := []string{}
+	for _, name := range names {
+		if !backupSkipped[name] && !strings.HasPrefix(name, "system.") {
+			collections = append(collections, name)
+		}
+	}
+	sort.Strings(collections)
+	return collections
+}
+
+type BackupHeader struct {
+	Format      string    `bson:"format"`
+	Version     int       `bson:"version"`
+	Database    string    `bson:"database"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	Collections []string  `bson:"collections"`
+}
+
+type BackupDocument struct {
+	Collection string   `bson:"collection"`
+	Document   bson.Raw `bson:"document"`
+}
+
+type BackupFooter struct {
+	Counts map[string]int `bson:"counts"`
+}
+
+// runCommand handles the "backup", "restore" and "backfill-history"
+// subcommands. It reports whether args named a subcommand, so main only
+// starts the server otherwise.
+func runCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "backup":
+		flags := flag.NewFlagSet("backup", flag.ExitOnError)
+		output := flags.String("o", "", "archive path (default: backup-<database>-<time>.jsonl.gz)")
+		flags.Parse(args[1:])
+		if *output == "" {
+			*output = fmt.Sprintf("backup-%s-%s.jsonl.gz", db.Name(), time.Now().Format("20060102-150405"))
+		}
+		return true, backupDatabase(*output)
+	case "restore":
+		flags := flag.NewFlagSet("restore", flag.ExitOnError)
+		flags.Usage = func() {
+			fmt.Fprintln(flags.Output(), "usage: restore <archive>")
+		}
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return true, errors.New("archive path is required")
+		}
+		return true, restoreDatabase(flags.Arg(0))
+	case "backfill-history":
+		return true, backfillWorkHistory()
+	}
+	return false, nil
+}
+
+func writeBackupLine(w io.Writer, value interface{}) error {
+	line, err := bson.MarshalExtJSON(value, true, false)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// backupDatabase dumps every collection of the database but the skipped
+// ones, so collections added by later features are included without changes
+// here.
+func backupDatabase(path string) error {
+	ctx := context.Background()
+
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": bson.M{"$not": bson.M{"$regex": "^system\\."}}})
+	if err != nil {
+		return fmt.Errorf("listing collections: %w", err)
+	}
+	names = backupCollections(names)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	w := bufio.NewWriter(gz)
+
+	header := BackupHeader{
+		Format:      backupFormat,
+		Version:     backupFormatVersion,
+		Database:    db.Name(),
+		CreatedAt:   time.Now(),
+		Collections: names,
+	}
+	if err := writeBackupLine(w, header); err != nil {
+		return err
+	}
+
+	footer := BackupFooter{Counts: map[string]int{}}
+	for _, name := range names {
+		cursor, err := db.Collection(name).Find(ctx, bson.M{})
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		for cursor.Next(ctx) {
+			if err := writeBackupLine(w, BackupDocument{Collection: name, Document: cursor.Current}); err != nil {
+				cursor.Close(ctx)
+				return err
+			}
+			footer.Counts[name]++
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		log.Printf("Backed up %d documents from %s", footer.Counts[name], name)
+	}
+
+	if err := writeBackupLine(w, footer); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	log.Printf("Backup written to %s", path)
+	return file.Close()
+}
+
+// restoreDatabase loads an archive into the configured database. It refuses
+// to write into collections that already hold documents, and checks the
+// footer counts so a truncated archive is reported.
+func restoreDatabase(path string) error {
+	ctx := context.Background()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	r := bufio.NewReader(gz)
+
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("reading archive header: %w", err)
+	}
+	var header BackupHeader
+	if err := bson.UnmarshalExtJSON(line, true, &header); err != nil || header.Format != backupFormat {
+		return errors.New("not a work tracking system backup")
+	}
+	if header.Version > backupFormatVersion {
+		return fmt.Errorf("backup version %d is newer than supported version %d", header.Version, backupFormatVersion)
+	}
+
+	// Archives made before the skipped collections were left out still hold them
+	header.Collections = backupCollections(header.Collections)
+	for _, name := range header.Collections {
+		count, err := db.Collection(name).CountDocuments(ctx, bson.M{})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("collection %s in database %s is not empty", name, db.Name())
+		}
+	}
+
+	counts := map[string]int{}
+	batches := map[string][]interface{}{}
+	flush := func(name string) error {
+		if len(batches[name]) == 0 {
+			return nil
+		}
+		if _, err := db.Collection(name).InsertMany(ctx, batches[name]); err != nil {
+			return fmt.Errorf("restoring %s: %w", name, err)
+		}
+		batches[name] = batches[name][:0]
+		return nil
+	}
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return errors.New("archive is truncated: footer missing")
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		var doc struct {
+			BackupDocument `bson:",inline"`
+			BackupFooter   `bson:",inline"`
+		}
+		if err := bson.UnmarshalExtJSON(line, true, &doc); err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		if doc.Counts != nil {
+			for _, name := range header.Collections {
+				if err := flush(name); err != nil {
+					return err
+				}
+				if counts[name] != doc.Counts[name] {
+					return fmt.Errorf("collection %s: restored %d of %d documents", name, counts[name], doc.Counts[name])
+				}
+				log.Printf("Restored %d documents into %s", counts[name], name)
+			}
+			return nil
+		}
+		if backupSkipped[doc.Collection] {
+			continue
+		}
+		batches[doc.Collection] = append(batches[doc.Collection], doc.Document)
+		counts[doc.Collection]++
+		if len(batches[doc.Collection]) >= backupBatchSize {
+			if err := flush(doc.Collection); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackupCollections(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{
+			"sorted",
+			[]string{"works", "employees", "audit_log"},
+			[]string{"audit_log", "employees", "works"},
+		},
+		{
+			"change stream state is left out",
+			[]string{"works", "event_stream_positions", "handled_events", "work_events"},
+			[]string{"work_events", "works"},
+		},
+		{
+			"system collections are left out",
+			[]string{"system.views", "employees"},
+			[]string{"employees"},
+		},
+		{"nothing to back up", []string{"handled_events"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backupCollections(tt.names); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backupCollections(%v) = %v, want %v", tt.names, got, tt.want)
+			}
+		})
+	}
+}
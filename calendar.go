@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// calendarFeedDays is how far back the iCal feed publishes works.
+const calendarFeedDays = 180
+
+// CalendarFeed grants read access to the works of one employee or team
+// through a secret token, so calendar apps can subscribe without a login.
+type CalendarFeed struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Token      string             `json:"token" bson:"token"`
+	EmployeeID primitive.ObjectID `json:"employeeId,omitempty" bson:"employeeId,omitempty"`
+	TeamID     primitive.ObjectID `json:"teamId,omitempty" bson:"teamId,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarFeedOwner maps the route to the field the feed is scoped by.
+func calendarFeedOwner(c *fiber.Ctx) (string, string) {
+	if strings.HasPrefix(c.Path(), "/api/teams/") {
+		return "teamId", "teams"
+	}
+	return "employeeId", "employees"
+}
+
+// createCalendarFeed issues a new feed token for an employee or team. Any
+// earlier token of the same owner stops working.
+func createCalendarFeed(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	field, collection := calendarFeedOwner(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.Collection(collection).FindOne(ctx, bson.M{"_id": id}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Owner not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch owner: " + err.Error()})
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token: " + err.Error()})
+	}
+	feed := CalendarFeed{ID: primitive.NewObjectID(), Token: token, CreatedAt: time.Now()}
+	if field == "teamId" {
+		feed.TeamID = id
+	} else {
+		feed.EmployeeID = id
+	}
+
+	if _, err := db.Collection("calendar_feeds").DeleteMany(ctx, bson.M{field: id}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke old feed: " + err.Error()})
+	}
+	if _, err := db.Collection("calendar_feeds").InsertOne(ctx, feed); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create calendar feed: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Takvim bağlantısı oluşturulurken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Takvim bağlantısı oluşturuldu.",
+		"data": fiber.Map{
+			"feed": feed,
+			"url":  c.BaseURL() + "/api/calendar/" + token + ".ics",
+		},
+	})
+}
+
+// deleteCalendarFeed revokes the feed token of an employee or team.
+func deleteCalendarFeed(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	field, _ := calendarFeedOwner(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("calendar_feeds").DeleteMany(ctx, bson.M{field: id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke calendar feed: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Calendar feed not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Calendar feed revoked successfully"})
+}
+
+// icsEscape escapes text values as required by RFC 5545.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeICSLine folds content lines longer than 75 octets without splitting
+// UTF-8 sequences.
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// buildICS renders works as VEVENTs. Works still running end at the time the
+// feed is generated and are marked tentative.
+func buildICS(name string, works []Work, withEmployee bool) string {
+	var b strings.Builder
+	now := time.Now()
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//work-tracking-system//TR",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsEscape(name),
+		"X-PUBLISHED-TTL:PT15M",
+	} {
+		writeICSLine(&b, line)
+	}
+
+	for _, work := range works {
+		summary := label(workTypeLabels, work.WorkType)
+		if work.Description != "" {
+			summary += ": " + work.Description
+		}
+		if withEmployee {
+			summary = work.EmployeeName + " - " + summary
+		}
+		end, status := work.EndTime, "CONFIRMED"
+		if work.Status != "completed" {
+			summary = "[" + label(workStatusLabels, work.Status) + "] " + summary
+			end, status = now, "TENTATIVE"
+		}
+		if !end.After(work.StartTime) {
+			end = work.StartTime.Add(time.Minute)
+		}
+		description := fmt.Sprintf("Personel: %s\nİş türü: %s\nDurum: %s",
+			work.EmployeeName, label(workTypeLabels, work.WorkType), label(workStatusLabels, work.Status))
+		if work.DurationMinutes > 0 {
+			description += "\nSüre: " + formatDuration(work.DurationMinutes)
+		}
+		if work.VideoLink != "" {
+			description += "\nVideo: " + work.VideoLink
+		}
+
+		lines := []string{
+			"BEGIN:VEVENT",
+			"UID:" + work.ID.Hex() + "@work-tracking-system",
+			"DTSTAMP:" + icsTime(now),
+			"DTSTART:" + icsTime(work.StartTime),
+			"DTEND:" + icsTime(end),
+			"SUMMARY:" + icsEscape(summary),
+			"DESCRIPTION:" + icsEscape(description),
+			"STATUS:" + status,
+		}
+		// Links stored before they were validated are left out rather than
+		// written unescaped
+		if work.VideoLink != "" && validateVideoLink(work.VideoLink) == nil {
+			lines = append(lines, "URL:"+work.VideoLink)
+		}
+		lines = append(lines, "END:VEVENT")
+		for _, line := range lines {
+			writeICSLine(&b, line)
+		}
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// getCalendarFeed serves the iCal feed for a token. Completed works become
+// events, in-progress and paused works show as ongoing events.
+func getCalendarFeed(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var feed CalendarFeed
+	if err := db.Collection("calendar_feeds").FindOne(ctx, bson.M{"token": token}).Decode(&feed); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Calendar feed not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch calendar feed: " + err.Error()})
+	}
+
+	filter := bson.M{
+		"status":    bson.M{"$in": []string{"completed", "in_progress", "paused"}},
+		"startTime": bson.M{"$gte": time.Now().AddDate(0, 0, -calendarFeedDays)},
+		"deletedAt": bson.M{"$exists": false},
+	}
+	var name string
+	if !feed.TeamID.IsZero() {
+		var team Team
+		if err := db.Collection("teams").FindOne(ctx, bson.M{"_id": feed.TeamID}).Decode(&team); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Team not found"})
+		}
+		cursor, err := db.Collection("employees").Find(ctx, bson.M{"teamId": team.ID, "deletedAt": bson.M{"$exists": false}})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch team members: " + err.Error()})
+		}
+		var members []Employee
+		if err = cursor.All(ctx, &members); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode team members: " + err.Error()})
+		}
+		ids := []primitive.ObjectID{}
+		for _, member := range members {
+			ids = append(ids, member.ID)
+		}
+		filter["employeeId"] = bson.M{"$in": ids}
+		name = team.Name + " - İşler"
+	} else {
+		var employee Employee
+		err := db.Collection("employees").FindOne(ctx, bson.M{"_id": feed.EmployeeID, "deletedAt": bson.M{"$exists": false}}).Decode(&employee)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		}
+		filter["employeeId"] = employee.ID
+		name = employee.Name + " - İşler"
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, filter, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="isler.ics"`)
+	return c.SendString(buildICS(name, works, !feed.TeamID.IsZero()))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestICSEscape(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "Video kurgu", "Video kurgu"},
+		{"backslash", `C:\videos`, `C:\\videos`},
+		{"separators", "a;b,c", `a\;b\,c`},
+		{"newline", "satır 1\nsatır 2", `satır 1\nsatır 2`},
+		{"windows newline", "satır 1\r\nsatır 2", `satır 1\nsatır 2`},
+		{"carriage return", "satır 1\rsatır 2", `satır 1\nsatır 2`},
+		{"escaped backslash before separator", `\;`, `\\\;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := icsEscape(tt.input); got != tt.want {
+				t.Errorf("icsEscape(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteICSLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"short line", "SUMMARY:Video", "SUMMARY:Video\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"folded once", strings.Repeat("a", 80), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 5) + "\r\n"},
+		{"continuation lines hold 74 octets", strings.Repeat("a", 75+74+1), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		{"multi-byte character is not split", strings.Repeat("a", 74) + "ş", strings.Repeat("a", 74) + "\r\n ş\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICSLine(&b, tt.input)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeICSLine(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteICSLineFolding(t *testing.T) {
+	input := "DESCRIPTION:" + strings.Repeat("Çalışma günlüğü; ", 20)
+	var b strings.Builder
+	writeICSLine(&b, input)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	var unfolded strings.Builder
+	for i, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line %d is %d octets, want at most 75", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Fatalf("continuation line %d does not start with a space: %q", i, line)
+			}
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+	if unfolded.String() != input {
+		t.Errorf("unfolded line = %q, want %q", unfolded.String(), input)
+	}
+}
+
+func TestValidateVideoLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://youtu.be/abc", false},
+		{"http://example.com/video?id=1&t=2", false},
+		{"youtu.be/abc", true},
+		{"javascript:alert(1)", true},
+		{"https://", true},
+		{"https://example.com/v\r\nEND:VEVENT", true},
+		{"https://example.com/v\nX-INJECTED:1", true},
+		{"https://example.com/\tv", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			err := validateVideoLink(tt.link)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateVideoLink(%q) error = %v, wantErr %v", tt.link, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildICSInjectedLink(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	works := []Work{{
+		ID:        primitive.NewObjectID(),
+		WorkType:  "video",
+		VideoLink: "https://example.com/v\r\nEND:VEVENT\r\nBEGIN:VEVENT\r\nSUMMARY:Sahte",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Status:    "completed",
+	}}
+
+	ics := buildICS("Takvim", works, false)
+	events := 0
+	for _, line := range strings.Split(ics, "\r\n") {
+		if line == "BEGIN:VEVENT" {
+			events++
+		}
+		if strings.HasPrefix(line, "URL:") || strings.HasPrefix(line, "SUMMARY:Sahte") {
+			t.Errorf("injected line %q written to the feed", line)
+		}
+	}
+	if events != 1 {
+		t.Errorf("feed has %d events, want 1:\n%s", events, ics)
+	}
+}
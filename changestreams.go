@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// changeStreamRetry is how long a watcher waits before reopening a failed
+// change stream.
+const changeStreamRetry = 10 * time.Second
+
+// changeStreamHistoryLost is returned when the stored resume token is no
+// longer in the oplog.
+const changeStreamHistoryLost = 286
+
+// StreamPosition is the last change of a collection that was published, so a
+// restarted server resumes where it stopped instead of missing events.
+type StreamPosition struct {
+	Collection string    `bson:"_id"`
+	Token      bson.Raw  `bson:"token"`
+	UpdatedAt  time.Time `bson:"updatedAt"`
+}
+
+// ChangeEvent is the part of a change stream document the watchers use.
+type ChangeEvent struct {
+	ID            bson.Raw            `bson:"_id"`
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	WallTime      time.Time           `bson:"wallTime"` // Only sent by MongoDB 6.0 and later
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.Raw `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields   bson.M           `bson:"updatedFields"`
+		RemovedFields   []string         `bson:"removedFields"`
+		TruncatedArrays []TruncatedArray `bson:"truncatedArrays"`
+	} `bson:"updateDescription"`
+}
+
+type TruncatedArray struct {
+	Field   string `json:"field" bson:"field"`
+	NewSize int    `json:"newSize" bson:"newSize"`
+}
+
+// handledEventsTTL is how long claimEvent remembers handled events.
+const handledEventsTTL = 30 * 24 * time.Hour
+
+// watchChangeStreams derives work and employee events and the work history
+// from the database instead of the handlers, so writes from any code path
+// (imports, restores, other instances) are handled exactly like API writes.
+// Without a replica set there are no change streams, and so no events, so
+// the server refuses to start.
+func watchChangeStreams() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := requireReplicaSet(ctx); err != nil {
+		log.Fatalf("MongoDB must run as a replica set for change streams (start mongod with --replSet and add ?replicaSet=<name> to MONGODB_URI): %v", err)
+	}
+	if err := ensureWorkEventIndexes(ctx); err != nil {
+		log.Printf("Error creating work history indexes: %v", err)
+	}
+	if err := ensureEventIndexes(ctx); err != nil {
+		log.Printf("Error creating event indexes: %v", err)
+	}
+
+	go watchCollection("works", func(change ChangeEvent) {
+		recordWorkHistory(change)
+		publishWorkChange(change)
+	})
+	go watchCollection("employees", publishEmployeeChange)
+}
+
+// requireReplicaSet returns an error unless the server is a replica set
+// member.
+func requireReplicaSet(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" {
+		return errors.New("the server is a standalone instance")
+	}
+	return nil
+}
+
+// ensureEventIndexes creates the indexes that make event subscribers
+// idempotent when a change is replayed after a restart, and the one that
+// removes expired live stream tickets.
+func ensureEventIndexes(ctx context.Context) error {
+	_, err := db.Collection("webhook_deliveries").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "webhookId", Value: 1}, {Key: "eventId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "eventId", Value: 1}, {Key: "employeeId", Value: 1}, {Key: "kind", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"eventId": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("handled_events").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(handledEventsTTL.Seconds())),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("stream_tickets").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// claimEvent records that handler is handling an event and returns false if
+// it already did, for side effects that cannot be undone such as e-mails.
+func claimEvent(ctx context.Context, handler string, eventID primitive.ObjectID) bool {
+	_, err := db.Collection("handled_events").InsertOne(ctx, bson.M{
+		"_id":       handler + ":" + eventID.Hex(),
+		"createdAt": time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error recording %s for event %s: %v", handler, eventID.Hex(), err)
+	}
+	return true
+}
+
+// changeEventID derives the ID of an event published for a change from the
+// change's resume token, so a replayed change publishes the same IDs. The
+// cluster time takes the place of the ObjectID timestamp.
+func changeEventID(change ChangeEvent, eventType string) primitive.ObjectID {
+	sum := sha256.Sum256(append(append([]byte{}, change.ID...), eventType...))
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[:4], change.ClusterTime.T)
+	copy(id[4:], sum[:8])
+	return id
+}
+
+// watchCollection follows a collection's change stream forever. The resume
+// token of a change is stored once every subscriber has handled its events,
+// so events are delivered at least once across restarts. Subscribers
+// recognise replayed events by their ID.
+func watchCollection(name string, handle func(ChangeEvent)) {
+	for {
+		err := followChangeStream(name, handle)
+		log.Printf("Change stream on %s stopped: %v (change streams require a MongoDB replica set), retrying in %s", name, err, changeStreamRetry)
+		time.Sleep(changeStreamRetry)
+	}
+}
+
+func followChangeStream(name string, handle func(ChangeEvent)) error {
+	ctx := context.Background()
+	positions := db.Collection("event_stream_positions")
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	var position StreamPosition
+	err := positions.FindOne(ctx, bson.M{"_id": name}).Decode(&position)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if len(position.Token) > 0 {
+		opts.SetResumeAfter(position.Token)
+	}
+
+	stream, err := db.Collection(name).Watch(ctx, mongo.Pipeline{}, opts)
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLost) {
+		log.Printf("Resume token for %s is no longer in the oplog, events since %s are lost", name, position.UpdatedAt.Format(time.RFC3339))
+		if _, err := positions.DeleteOne(ctx, bson.M{"_id": name}); err != nil {
+			return err
+		}
+		stream, err = db.Collection(name).Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	}
+	if err != nil {
+		return err
+	}
+	defer stream.Close(ctx)
+
+	for stream.Next(ctx) {
+		var change ChangeEvent
+		if err := stream.Decode(&change); err != nil {
+			log.Printf("Error decoding %s change: %v", name, err)
+		} else {
+			handle(change)
+			flushEvents()
+		}
+
+		_, err := positions.UpdateOne(ctx,
+			bson.M{"_id": name},
+			bson.M{"$set": bson.M{"token": stream.ResumeToken(), "updatedAt": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			log.Printf("Error storing %s stream position: %v", name, err)
+		}
+	}
+	return stream.Err()
+}
+
+// publishChange publishes an event derived from a change under the ID
+// changeEventID gives it.
+func publishChange(change ChangeEvent, eventType string, data interface{}) {
+	publishEventWithID(changeEventID(change, eventType), eventType, data)
+}
+
+// publishWorkChange maps a works change to domain events. Updates always
+// produce work.updated and, depending on the changed fields, one of the more
+// specific events.
+func publishWorkChange(change ChangeEvent) {
+	if len(change.FullDocument) == 0 {
+		// Deleted since the change, or a hard delete
+		return
+	}
+	var work Work
+	if err := bson.Unmarshal(change.FullDocument, &work); err != nil {
+		log.Printf("Error decoding changed work: %v", err)
+		return
+	}
+
+	if _, ok := change.UpdateDescription.UpdatedFields[nameSyncMarker]; ok {
+		// Name copies rewritten after a rename, published as employee.renamed
+		return
+	}
+
+	switch change.OperationType {
+	case "insert":
+		publishChange(change, EventWorkCreated, work)
+	case "update", "replace":
+		publishChange(change, EventWorkUpdated, work)
+
+		fields := change.UpdateDescription.UpdatedFields
+		if fields["status"] == "completed" {
+			publishChange(change, EventWorkCompleted, work)
+		}
+		// Only a new reviews array counts, renames touch "reviews.N.reviewerName"
+		if _, ok := fields["reviews"]; ok && len(work.Reviews) > 0 {
+			publishChange(change, EventReviewSubmitted, work)
+		}
+		if fields["revisionStatus"] == "approved" {
+			publishChange(change, EventVideoApproved, work)
+		}
+	}
+}
+
+// publishEmployeeChange publishes employee.deleted when an employee is soft
+// deleted and employee.renamed when the name changes otherwise.
+func publishEmployeeChange(change ChangeEvent) {
+	if change.OperationType != "update" || len(change.FullDocument) == 0 {
+		return
+	}
+	fields := change.UpdateDescription.UpdatedFields
+	var employee Employee
+	if err := bson.Unmarshal(change.FullDocument, &employee); err != nil {
+		log.Printf("Error decoding changed employee: %v", err)
+		return
+	}
+	if _, ok := fields["deletedAt"]; ok {
+		publishChange(change, EventEmployeeDeleted, fiber.Map{"id": employee.ID, "name": employee.Name, "deletedAt": employee.DeletedAt})
+		return
+	}
+	if _, ok := fields["name"]; ok {
+		publishChange(change, EventEmployeeRenamed, fiber.Map{"id": employee.ID, "name": employee.Name})
+	}
+}
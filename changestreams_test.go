@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChangeEventID(t *testing.T) {
+	token := func(data string) bson.Raw {
+		raw, err := bson.Marshal(bson.M{"_data": data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	change := ChangeEvent{ID: token("8263A1"), ClusterTime: primitive.Timestamp{T: 1700000000, I: 3}}
+	replayed := ChangeEvent{ID: token("8263A1"), ClusterTime: primitive.Timestamp{T: 1700000000, I: 3}}
+	other := ChangeEvent{ID: token("8263A2"), ClusterTime: primitive.Timestamp{T: 1700000000, I: 4}}
+
+	id := changeEventID(change, EventWorkUpdated)
+	if got := changeEventID(replayed, EventWorkUpdated); got != id {
+		t.Errorf("replayed change got ID %s, want %s", got.Hex(), id.Hex())
+	}
+	if got := changeEventID(change, EventWorkCompleted); got == id {
+		t.Error("two events of one change got the same ID")
+	}
+	if got := changeEventID(other, EventWorkUpdated); got == id {
+		t.Error("two changes got the same ID")
+	}
+	if got := id.Timestamp().Unix(); got != 1700000000 {
+		t.Errorf("ID timestamp = %d, want the cluster time 1700000000", got)
+	}
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Chat notification kinds a channel can be routed
+const (
+	NotifyReviewRequested   = "review_requested"
+	NotifyRevisionRequested = "revision_requested"
+	NotifyVideoApproved     = "video_approved"
+)
+
+var chatNotificationKinds = map[string]bool{
+	NotifyReviewRequested:   true,
+	NotifyRevisionRequested: true,
+	NotifyVideoApproved:     true,
+}
+
+type ChatNotification struct {
+	Kind string
+	Text string
+	Work Work // The video the notification is about, used for routing
+}
+
+// Notifier posts a notification to an external chat service.
+type Notifier interface {
+	Notify(ctx context.Context, notification ChatNotification) error
+}
+
+// IncomingWebhookNotifier posts Slack compatible incoming webhook payloads,
+// which Mattermost accepts as well. Channel and Username override the
+// webhook defaults where the service allows it.
+type IncomingWebhookNotifier struct {
+	URL      string
+	Channel  string
+	Username string
+}
+
+func (n IncomingWebhookNotifier) Notify(ctx context.Context, notification ChatNotification) error {
+	payload := map[string]string{"text": notification.Text}
+	if n.Channel != "" {
+		payload["channel"] = n.Channel
+	}
+	if n.Username != "" {
+		payload["username"] = n.Username
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		// The URL is the channel's secret, keep it out of logs and responses
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = maskWebhookURL(urlErr.URL)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// ChatChannel is a routing rule: notifications of the listed kinds go to the
+// channel when they match its optional work type and team filters.
+type ChatChannel struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name       string             `json:"name" bson:"name"`
+	WebhookURL string             `json:"webhookUrl" bson:"webhookUrl"`
+	Channel    string             `json:"channel,omitempty" bson:"channel,omitempty"` // Overrides the webhook's default channel
+	Kinds      []string           `json:"kinds" bson:"kinds"`
+	WorkTypes  []string           `json:"workTypes,omitempty" bson:"workTypes,omitempty"` // Empty matches every work type
+	TeamID     primitive.ObjectID `json:"teamId,omitempty" bson:"teamId,omitempty"`       // Only works of this team's members
+	Active     bool               `json:"active" bson:"active"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// maskWebhookURL hides an incoming webhook URL, whose path is its secret,
+// showing only the host and the last characters.
+func maskWebhookURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "****"
+	}
+	rest := u.EscapedPath()
+	if u.RawQuery != "" {
+		rest += "?" + u.RawQuery
+	}
+	if len(rest) > 4 {
+		rest = rest[len(rest)-4:]
+	}
+	return u.Scheme + "://" + u.Host + "/****" + rest
+}
+
+func (channel ChatChannel) notifier() Notifier {
+	return IncomingWebhookNotifier{URL: channel.WebhookURL, Channel: channel.Channel, Username: "İş Takip Sistemi"}
+}
+
+func (channel ChatChannel) matches(ctx context.Context, work Work) bool {
+	if len(channel.WorkTypes) > 0 {
+		found := false
+		for _, workType := range channel.WorkTypes {
+			found = found || workType == work.WorkType
+		}
+		if !found {
+			return false
+		}
+	}
+	if !channel.TeamID.IsZero() {
+		count, err := db.Collection("employees").CountDocuments(ctx, bson.M{"_id": work.EmployeeID, "teamId": channel.TeamID})
+		if err != nil || count == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func chatWorkLine(work Work) string {
+	line := work.Description
+	if work.VideoLink != "" {
+		line += "\n" + work.VideoLink
+	}
+	return line
+}
+
+// chatNotificationFor turns a domain event into a chat notification, if the
+// event is one chat channels care about.
+func chatNotificationFor(ctx context.Context, event Event) (ChatNotification, bool) {
+	work, ok := event.Data.(Work)
+	if !ok {
+		return ChatNotification{}, false
+	}
+
+	switch event.Type {
+	case EventWorkCompleted:
+		if work.WorkType != "video" && work.WorkType != "revize" {
+			return ChatNotification{}, false
+		}
+		return ChatNotification{
+			Kind: NotifyReviewRequested,
+			Text: fmt.Sprintf(":movie_camera: *%s* bir videoyu tamamladı, inceleme bekliyor: %s", work.EmployeeName, chatWorkLine(work)),
+			Work: work,
+		}, true
+
+	case EventReviewSubmitted:
+		if work.ReviewedVideoID.IsZero() || len(work.Reviews) == 0 {
+			return ChatNotification{}, false
+		}
+		var video Work
+		if err := db.Collection("works").FindOne(ctx, bson.M{"_id": work.ReviewedVideoID}).Decode(&video); err != nil {
+			log.Printf("Error loading reviewed video for chat notification: %v", err)
+			return ChatNotification{}, false
+		}
+		review := work.Reviews[len(work.Reviews)-1]
+		text := fmt.Sprintf(":pencil2: *%s*, *%s* adlı personelin videosu için revizyon istedi: %s", review.ReviewerName, video.EmployeeName, chatWorkLine(video))
+		if review.Comment != "" {
+			text += "\n> " + review.Comment
+		}
+		return ChatNotification{Kind: NotifyRevisionRequested, Text: text, Work: video}, true
+
+	case EventVideoApproved:
+		return ChatNotification{
+			Kind: NotifyVideoApproved,
+			Text: fmt.Sprintf(":white_check_mark: *%s* adlı personelin videosu onaylandı: %s", work.EmployeeName, chatWorkLine(work)),
+			Work: work,
+		}, true
+	}
+	return ChatNotification{}, false
+}
+
+// notifyChatChannels is subscribed to the event bus and posts matching events
+// to every chat channel routed for them. Chat messages are best effort, so
+// failures are only logged.
+func notifyChatChannels(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	notification, ok := chatNotificationFor(ctx, event)
+	if !ok || !claimEvent(ctx, "chat", event.ID) {
+		return
+	}
+
+	cursor, err := db.Collection("chat_channels").Find(ctx, bson.M{"active": true, "kinds": notification.Kind})
+	if err != nil {
+		log.Printf("Error fetching chat channels: %v", err)
+		return
+	}
+	var channels []ChatChannel
+	if err := cursor.All(ctx, &channels); err != nil {
+		log.Printf("Error decoding chat channels: %v", err)
+		return
+	}
+
+	for _, channel := range channels {
+		if !channel.matches(ctx, notification.Work) {
+			continue
+		}
+		if err := channel.notifier().Notify(ctx, notification); err != nil {
+			log.Printf("Error notifying chat channel %s: %v", channel.Name, err)
+		}
+	}
+}
+
+func validateChatChannel(channel ChatChannel) error {
+	if channel.Name == "" {
+		return fmt.Errorf("channel name is required")
+	}
+	if err := validateWebhookURL(channel.WebhookURL); err != nil {
+		return err
+	}
+	if len(channel.Kinds) == 0 {
+		return fmt.Errorf("at least one notification kind is required")
+	}
+	for _, kind := range channel.Kinds {
+		if !chatNotificationKinds[kind] {
+			return fmt.Errorf("unknown notification kind %q", kind)
+		}
+	}
+	for _, workType := range channel.WorkTypes {
+		if _, ok := workTypeLabels[workType]; !ok {
+			return fmt.Errorf("unknown work type %q", workType)
+		}
+	}
+	return nil
+}
+
+func createChatChannel(c *fiber.Ctx) error {
+	var channel ChatChannel
+	if err := c.BodyParser(&channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateChatChannel(channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz kanal ayarı: " + err.Error(),
+		})
+	}
+	channel.ID = primitive.NewObjectID()
+	channel.Active = true
+	channel.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Collection("chat_channels").InsertOne(ctx, channel); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create chat channel: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Sohbet kanalı eklenirken bir hata oluştu.",
+		})
+	}
+
+	channel.WebhookURL = maskWebhookURL(channel.WebhookURL)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Sohbet kanalı başarıyla eklendi.",
+		"data":  channel,
+	})
+}
+
+func getChatChannels(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("chat_channels").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch chat channels: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	channels := []ChatChannel{}
+	if err = cursor.All(ctx, &channels); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode chat channels: " + err.Error()})
+	}
+	for i := range channels {
+		channels[i].WebhookURL = maskWebhookURL(channels[i].WebhookURL)
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": channels,
+	})
+}
+
+func updateChatChannel(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		Name       *string   `json:"name"`
+		WebhookURL *string   `json:"webhookUrl"`
+		Channel    *string   `json:"channel"`
+		Kinds      *[]string `json:"kinds"`
+		WorkTypes  *[]string `json:"workTypes"`
+		TeamID     *string   `json:"teamId"` // "" removes the team filter
+		Active     *bool     `json:"active"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var channel ChatChannel
+	if err := db.Collection("chat_channels").FindOne(ctx, bson.M{"_id": id}).Decode(&channel); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Chat channel not found"})
+	}
+	if update.Name != nil {
+		channel.Name = *update.Name
+	}
+	if update.WebhookURL != nil {
+		channel.WebhookURL = *update.WebhookURL
+	}
+	if update.Channel != nil {
+		channel.Channel = *update.Channel
+	}
+	if update.Kinds != nil {
+		channel.Kinds = *update.Kinds
+	}
+	if update.WorkTypes != nil {
+		channel.WorkTypes = *update.WorkTypes
+	}
+	if update.TeamID != nil {
+		channel.TeamID = primitive.NilObjectID
+		if *update.TeamID != "" {
+			if channel.TeamID, err = primitive.ObjectIDFromHex(*update.TeamID); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid team ID format"})
+			}
+		}
+	}
+	if update.Active != nil {
+		channel.Active = *update.Active
+	}
+	if err := validateChatChannel(channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz kanal ayarı: " + err.Error(),
+		})
+	}
+
+	set := bson.M{
+		"name":       channel.Name,
+		"webhookUrl": channel.WebhookURL,
+		"channel":    channel.Channel,
+		"kinds":      channel.Kinds,
+		"workTypes":  channel.WorkTypes,
+		"active":     channel.Active,
+	}
+	change := bson.M{"$set": set}
+	if channel.TeamID.IsZero() {
+		change["$unset"] = bson.M{"teamId": ""}
+	} else {
+		set["teamId"] = channel.TeamID
+	}
+
+	result, err := db.Collection("chat_channels").UpdateOne(ctx, bson.M{"_id": id}, change)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update chat channel: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Chat channel not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Chat channel updated successfully"})
+}
+
+func deleteChatChannel(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("chat_channels").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete chat channel: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Chat channel not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Chat channel deleted successfully"})
+}
+
+// testChatChannel sends a test message so the webhook URL can be checked.
+func testChatChannel(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var channel ChatChannel
+	if err := db.Collection("chat_channels").FindOne(ctx, bson.M{"_id": id}).Decode(&channel); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Chat channel not found"})
+	}
+
+	err = channel.notifier().Notify(ctx, ChatNotification{Text: ":wave: İş Takip Sistemi test mesajı"})
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Test mesajı gönderilemedi: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Test mesajı gönderildi.",
+	})
+}
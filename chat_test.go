@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskWebhookURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://hooks.slack.com/services/T000/B000/XXXXabcd", "https://hooks.slack.com/****abcd"},
+		{"https://chat.example.com/hooks/abc", "https://chat.example.com/****/abc"},
+		{"https://chat.example.com/hooks?token=secret1234", "https://chat.example.com/****1234"},
+		{"not a url", "****"},
+	}
+	for _, tt := range tests {
+		got := maskWebhookURL(tt.url)
+		if got != tt.want {
+			t.Errorf("maskWebhookURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+		if strings.Contains(got, "T000") || strings.Contains(got, "secret") {
+			t.Errorf("maskWebhookURL(%q) = %q leaks the secret part", tt.url, got)
+		}
+	}
+}
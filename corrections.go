@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// WorkChange is one admin correction of a work, stored in "work_changes".
+type WorkChange struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkID      primitive.ObjectID `json:"workId" bson:"workId"`
+	Reason      string             `json:"reason" bson:"reason"`
+	CorrectedBy string             `json:"correctedBy" bson:"correctedBy"` // Admin who made the correction
+	Changes     []FieldChange      `json:"changes" bson:"changes"`
+	ChangedAt   time.Time          `json:"changedAt" bson:"changedAt"`
+}
+
+// revisionStatuses are the values the admin panel knows for revisionStatus.
+var revisionStatuses = map[string]bool{"": true, "pending": true, "approved": true, "needs_revision": true}
+
+// correctWork lets an admin edit any field of a work. Duration fields are
+// recomputed and every changed field is kept as a before/after pair.
+func correctWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var correction struct {
+		EmployeeID     *primitive.ObjectID `json:"employeeId"`
+		WorkType       *string             `json:"workType"`
+		Description    *string             `json:"description"`
+		VideoLink      *string             `json:"videoLink"`
+		IsFirstVideo   *bool               `json:"isFirstVideo"`
+		IsRevision     *bool               `json:"isRevision"`
+		IsReviewed     *bool               `json:"isReviewed"`
+		TaskID         *primitive.ObjectID `json:"taskId"`
+		StartTime      *time.Time          `json:"startTime"`
+		EndTime        *time.Time          `json:"endTime"`
+		PausedMinutes  *int                `json:"pausedMinutes"`
+		Status         *string             `json:"status"`
+		RevisionStatus *string             `json:"revisionStatus"`
+		Reason         string              `json:"reason"`
+	}
+	if err := c.BodyParser(&correction); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if correction.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A reason for the correction is required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen düzeltme sebebini giriniz.",
+		})
+	}
+
+	if correction.WorkType != nil {
+		if _, ok := workTypeLabels[*correction.WorkType]; !ok {
+			return invalidCorrection(c, "workType")
+		}
+	}
+	if correction.Status != nil {
+		if _, ok := workStatusLabels[*correction.Status]; !ok {
+			return invalidCorrection(c, "status")
+		}
+	}
+	if correction.RevisionStatus != nil && !revisionStatuses[*correction.RevisionStatus] {
+		return invalidCorrection(c, "revisionStatus")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	raw, err := db.Collection("works").FindOne(ctx, bson.M{"_id": id}).Raw()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Work not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work: " + err.Error()})
+	}
+	var work Work
+	var current bson.M
+	if err := bson.Unmarshal(raw, &work); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode work: " + err.Error()})
+	}
+	if err := bson.Unmarshal(raw, &current); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode work: " + err.Error()})
+	}
+
+	updateFields := bson.M{}
+	if correction.EmployeeID != nil {
+		var employee Employee
+		err := db.Collection("employees").FindOne(ctx, bson.M{"_id": *correction.EmployeeID}).Decode(&employee)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Employee not found",
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Personel bulunamadı",
+			})
+		}
+		updateFields["employeeId"] = employee.ID
+		updateFields["employeeName"] = employee.Name
+	}
+	if correction.WorkType != nil {
+		updateFields["workType"] = *correction.WorkType
+	}
+	if correction.Description != nil {
+		updateFields["description"] = *correction.Description
+	}
+	if correction.VideoLink != nil {
+		if err := validateVideoLink(*correction.VideoLink); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Geçersiz video bağlantısı. Lütfen http veya https ile başlayan bir adres giriniz.",
+			})
+		}
+		updateFields["videoLink"] = *correction.VideoLink
+	}
+	if correction.IsFirstVideo != nil {
+		updateFields["isFirstVideo"] = *correction.IsFirstVideo
+	}
+	if correction.IsRevision != nil {
+		updateFields["isRevision"] = *correction.IsRevision
+	}
+	if correction.IsReviewed != nil {
+		updateFields["isReviewed"] = *correction.IsReviewed
+	}
+	if correction.TaskID != nil {
+		updateFields["taskId"] = *correction.TaskID
+	}
+	if correction.Status != nil {
+		updateFields["status"] = *correction.Status
+	}
+	if correction.RevisionStatus != nil {
+		updateFields["revisionStatus"] = *correction.RevisionStatus
+	}
+
+	startTime, endTime, pausedMinutes := work.StartTime, work.EndTime, work.PausedMinutes
+	if correction.StartTime != nil {
+		startTime = *correction.StartTime
+		updateFields["startTime"] = startTime
+	}
+	if correction.EndTime != nil {
+		endTime = *correction.EndTime
+		updateFields["endTime"] = endTime
+	}
+	if correction.PausedMinutes != nil {
+		pausedMinutes = *correction.PausedMinutes
+		updateFields["pausedMinutes"] = pausedMinutes
+	}
+	if correction.StartTime != nil || correction.EndTime != nil || correction.PausedMinutes != nil {
+		if !endTime.IsZero() {
+			duration := endTime.Sub(startTime) - time.Duration(pausedMinutes)*time.Minute
+			if duration < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "End time must be after start time",
+					"type":  "warning",
+					"title": "Uyarı",
+					"text":  "Bitiş zamanı başlangıç zamanından sonra olmalıdır.",
+				})
+			}
+			updateFields["duration"] = duration.String()
+			updateFields["durationMinutes"] = int(duration.Minutes())
+		}
+	}
+
+	// Only fields whose stored value actually changes are recorded
+	var changes []FieldChange
+	for field, after := range updateFields {
+		before := current[field]
+		if sameValue(before, after) {
+			delete(updateFields, field)
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Before: before, After: after})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	if len(changes) == 0 {
+		return c.JSON(fiber.Map{"message": "Nothing to change"})
+	}
+
+	_, err = db.Collection("works").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update work: " + err.Error()})
+	}
+
+	change := WorkChange{
+		ID:          primitive.NewObjectID(),
+		WorkID:      id,
+		Reason:      correction.Reason,
+		CorrectedBy: adminName(c),
+		Changes:     changes,
+		ChangedAt:   time.Now(),
+	}
+	if _, err := db.Collection("work_changes").InsertOne(ctx, change); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to record change: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "İş kaydı düzeltildi.",
+		"data":  change,
+	})
+}
+
+func invalidCorrection(c *fiber.Ctx, field string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "Invalid " + field,
+		"type":  "warning",
+		"title": "Uyarı",
+		"text":  "Geçersiz değer: " + field,
+	})
+}
+
+// sameValue compares a stored BSON value with a new one, treating times by
+// their instant since MongoDB stores them with millisecond precision. A
+// missing field equals the zero value.
+func sameValue(before, after interface{}) bool {
+	if before == nil {
+		return reflect.ValueOf(after).IsZero()
+	}
+	if t, ok := after.(time.Time); ok {
+		if stored, ok := before.(primitive.DateTime); ok {
+			return stored.Time().Equal(t.Truncate(time.Millisecond))
+		}
+		return false
+	}
+	if n, ok := after.(int); ok {
+		switch stored := before.(type) {
+		case int32:
+			return int(stored) == n
+		case int64:
+			return int(stored) == n
+		}
+		return false
+	}
+	return reflect.DeepEqual(before, after)
+}
+
+func getWorkChanges(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("work_changes").Find(
+		ctx,
+		bson.M{"workId": id},
+		options.Find().SetSort(bson.M{"changedAt": 1}),
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch changes: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	changes := []WorkChange{}
+	if err = cursor.All(ctx, &changes); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode changes: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": changes,
+	})
+}
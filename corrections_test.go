@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSameValue(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 30, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   bool
+	}{
+		{"missing equals zero string", nil, "", true},
+		{"missing differs from value", nil, "video", false},
+		{"missing equals zero time", nil, time.Time{}, true},
+		{"same string", "video", "video", true},
+		{"different string", "video", "software", false},
+		{"time at millisecond precision", primitive.NewDateTimeFromTime(start), start, true},
+		{"different time", primitive.NewDateTimeFromTime(start), start.Add(time.Second), false},
+		{"time against non-date", "2024-05-06", start, false},
+		{"int32 against int", int32(15), 15, true},
+		{"int64 against int", int64(15), 15, true},
+		{"different int", int32(15), 16, false},
+		{"int against string", "15", 15, false},
+		{"same bool", true, true, true},
+		{"same object ID", primitive.ObjectID{1}, primitive.ObjectID{1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameValue(tt.before, tt.after); got != tt.want {
+				t.Errorf("sameValue(%v, %v) = %v, want %v", tt.before, tt.after, got, tt.want)
+			}
+		})
+	}
+}
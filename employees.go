@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func restoreEmployee(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Anonymised employees have no identity left to restore
+	result, err := db.Collection("employees").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": true}, "anonymizedAt": bson.M{"$exists": false}},
+		bson.M{"$unset": bson.M{"deletedAt": ""}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to restore employee: " + err.Error()})
+	}
+
+	if result.ModifiedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Deleted employee not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Personel/stajyer geri yüklendi.",
+	})
+}
+
+// anonymizeEmployee honours a data-removal request. The employee's name is
+// replaced and the profile removed in the employee document, every
+// denormalised copy and the logs that keep snapshots, while works and their
+// durations stay in place for the aggregated stats.
+func anonymizeEmployee(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var employee Employee
+	err = db.Collection("employees").FindOne(ctx, bson.M{"_id": id}).Decode(&employee)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employee: " + err.Error()})
+	}
+	if employee.AnonymizedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Employee already anonymised"})
+	}
+
+	now := time.Now()
+	anonymousName := "Anonim Personel " + id.Hex()[18:]
+	employeeUpdate := bson.M{"name": anonymousName, "anonymizedAt": now}
+	if employee.DeletedAt == nil {
+		employeeUpdate["deletedAt"] = now
+	}
+	_, err = db.Collection("employees").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   employeeUpdate,
+		"$unset": bson.M{"email": "", "startDate": "", "endDate": ""},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to anonymise employee: " + err.Error()})
+	}
+
+	if err := renameEmployeeCopies(ctx, id, anonymousName); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to anonymise works: " + err.Error()})
+	}
+	replacer := anonymizeReplacer(employee, anonymousName)
+	if err := scrubWorkChangeNames(ctx, employee, replacer); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to anonymise work history: " + err.Error()})
+	}
+	if err := scrubEmployeeRecords(ctx, employee, replacer); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to anonymise stored records: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Personel/stajyer bilgileri anonimleştirildi.",
+	})
+}
+
+// anonymizeReplacer swaps an employee's name for the anonymous one and drops
+// their email address.
+func anonymizeReplacer(employee Employee, anonymousName string) *strings.Replacer {
+	var pairs []string
+	if employee.Name != "" {
+		pairs = append(pairs, employee.Name, anonymousName)
+	}
+	if employee.Email != "" {
+		pairs = append(pairs, employee.Email, "")
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// employeeName returns the stored name of an employee, so copies written into
+// works come from the employees collection rather than from the client.
+func employeeName(ctx context.Context, id primitive.ObjectID) (string, bool) {
+	var employee Employee
+	if err := db.Collection("employees").FindOne(ctx, bson.M{"_id": id}).Decode(&employee); err != nil {
+		return "", false
+	}
+	return employee.Name, true
+}
+
+// nameSyncMarker is set on works whose copy of an employee name is rewritten.
+const nameSyncMarker = "nameSyncedAt"
+
+// renameEmployeeCopies rewrites the employee name copied into works, tasks,
+// attendance, leaves and internship records.
+func renameEmployeeCopies(ctx context.Context, id primitive.ObjectID, newName string) error {
+	// The marker tells the works change stream to skip the per-work events,
+	// the rename is published once as employee.renamed
+	now := time.Now()
+	works := db.Collection("works")
+	if _, err := works.UpdateMany(ctx, bson.M{"employeeId": id}, bson.M{"$set": bson.M{"employeeName": newName, nameSyncMarker: now}}); err != nil {
+		return err
+	}
+	if _, err := works.UpdateMany(ctx, bson.M{"revisedBy": id}, bson.M{"$set": bson.M{"revisedByName": newName, nameSyncMarker: now}}); err != nil {
+		return err
+	}
+	_, err := works.UpdateMany(
+		ctx,
+		bson.M{"reviews.reviewerId": id},
+		bson.M{"$set": bson.M{"reviews.$[review].reviewerName": newName, nameSyncMarker: now}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"review.reviewerId": id}},
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("tasks").UpdateMany(ctx, bson.M{"employeeId": id}, bson.M{"$set": bson.M{"employeeName": newName}}); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("attendance").UpdateMany(ctx, bson.M{"employeeId": id}, bson.M{"$set": bson.M{"employeeName": newName}}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("leaves").UpdateMany(ctx, bson.M{"employeeId": id}, bson.M{"$set": bson.M{"employeeName": newName}}); err != nil {
+		return err
+	}
+
+	internships := db.Collection("internships")
+	if _, err := internships.UpdateMany(ctx, bson.M{"employeeId": id}, bson.M{"$set": bson.M{"employeeName": newName}}); err != nil {
+		return err
+	}
+	if _, err := internships.UpdateMany(ctx, bson.M{"mentorId": id}, bson.M{"$set": bson.M{"mentorName": newName}}); err != nil {
+		return err
+	}
+	_, err = db.Collection("evaluations").UpdateMany(ctx, bson.M{"mentorId": id}, bson.M{"$set": bson.M{"mentorName": newName}})
+	return err
+}
+
+// scrubWorkChangeNames rewrites an anonymised employee's name and email in the
+// records that keep copies of their works: the before/after values of work
+// corrections and the set/document payloads of the work history, including
+// reviewer and reviser names.
+func scrubWorkChangeNames(ctx context.Context, employee Employee, replacer *strings.Replacer) error {
+	workIDs, err := employeeWorkIDs(ctx, employee.ID)
+	if err != nil {
+		return err
+	}
+
+	changeFilter := []bson.M{{"workId": bson.M{"$in": workIDs}}}
+	if employee.Name != "" {
+		changeFilter = append(changeFilter, bson.M{"changes.before": employee.Name}, bson.M{"changes.after": employee.Name})
+	}
+	if err := scrubDocuments(ctx, "work_changes", bson.M{"$or": changeFilter}, []string{"changes"}, replacer); err != nil {
+		return err
+	}
+
+	// Rebuilding a work from its history must not bring the old name back
+	if len(workIDs) == 0 {
+		return nil
+	}
+	return scrubDocuments(ctx, "work_events", bson.M{"workId": bson.M{"$in": workIDs}}, []string{"document", "set"}, replacer)
+}
+
+// employeeWorkIDs lists the works an employee did, revised or reviewed, also
+// those that only their history still links to the employee.
+func employeeWorkIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	fromWorks, err := db.Collection("works").Distinct(ctx, "_id", bson.M{"$or": []bson.M{
+		{"employeeId": id}, {"revisedBy": id}, {"reviews.reviewerId": id},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	fromEvents, err := db.Collection("work_events").Distinct(ctx, "workId", bson.M{"$or": []bson.M{
+		{"document.employeeId": id}, {"document.revisedBy": id}, {"document.reviews.reviewerId": id},
+		{"set.employeeId": id}, {"set.revisedBy": id},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	workIDs := []primitive.ObjectID{}
+	for _, value := range append(fromWorks, fromEvents...) {
+		if workID, ok := value.(primitive.ObjectID); ok && !seen[workID] {
+			seen[workID] = true
+			workIDs = append(workIDs, workID)
+		}
+	}
+	return workIDs, nil
+}
+
+// scrubEmployeeRecords removes an anonymised employee from the records that
+// keep copies of documents: audit log snapshots, notifications, webhook
+// payloads and calendar feeds.
+func scrubEmployeeRecords(ctx context.Context, employee Employee, replacer *strings.Replacer) error {
+	id := employee.ID
+	mentions := regexp.QuoteMeta(id.Hex())
+	if employee.Name != "" {
+		mentions += "|" + regexp.QuoteMeta(employee.Name)
+	}
+
+	var auditFilter []bson.M
+	for _, field := range []string{"targetId", "actor.employeeId"} {
+		auditFilter = append(auditFilter, bson.M{field: id})
+	}
+	for _, side := range []string{"before", "after"} {
+		for _, field := range []string{"_id", "employeeId", "revisedBy", "reviews.reviewerId", "mentorId"} {
+			auditFilter = append(auditFilter, bson.M{side + "." + field: id})
+		}
+	}
+	if err := scrubDocuments(ctx, "audit_log", bson.M{"$or": auditFilter}, []string{"actor", "before", "after"}, replacer); err != nil {
+		return err
+	}
+	// The employee's own snapshots lose the profile fields too
+	_, err := db.Collection("audit_log").UpdateMany(ctx, bson.M{"targetType": "employees", "targetId": id}, bson.M{"$unset": bson.M{
+		"before.email": "", "before.startDate": "", "before.endDate": "",
+		"after.email": "", "after.startDate": "", "after.endDate": "",
+	}})
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("notifications").DeleteMany(ctx, bson.M{"employeeId": id}); err != nil {
+		return err
+	}
+	if employee.Name != "" {
+		filter := bson.M{"text": bson.M{"$regex": regexp.QuoteMeta(employee.Name)}}
+		if err := scrubDocuments(ctx, "notifications", filter, []string{"title", "text"}, replacer); err != nil {
+			return err
+		}
+	}
+
+	filter := bson.M{"payload": bson.M{"$regex": mentions}}
+	if err := scrubDocuments(ctx, "webhook_deliveries", filter, []string{"payload"}, replacer); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("calendar_feeds").DeleteMany(ctx, bson.M{"employeeId": id})
+	return err
+}
+
+// scrubDocuments rewrites the strings found anywhere in the given top-level
+// fields of the matching documents.
+func scrubDocuments(ctx context.Context, collection string, filter bson.M, fields []string, replacer *strings.Replacer) error {
+	cursor, err := db.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		update := bson.M{}
+		for _, field := range fields {
+			if value, ok := doc[field]; ok && value != nil {
+				update[field] = scrubValue(value, replacer)
+			}
+		}
+		if len(update) == 0 {
+			continue
+		}
+		if _, err := db.Collection(collection).UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": update}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func scrubValue(value interface{}, replacer *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replacer.Replace(v)
+	case bson.M:
+		for key, item := range v {
+			v[key] = scrubValue(item, replacer)
+		}
+		return v
+	case primitive.A:
+		for i, item := range v {
+			v[i] = scrubValue(item, replacer)
+		}
+		return v
+	}
+	return value
+}
+
+// validateEmployeeProfile checks the optional profile fields of an employee
+// and that the referenced team and manager exist.
+func validateEmployeeProfile(ctx context.Context, employee Employee) error {
+	if employee.Email != "" {
+		if _, err := mail.ParseAddress(employee.Email); err != nil {
+			return errors.New("invalid email address")
+		}
+	}
+	if employee.StartDate != nil && employee.EndDate != nil && employee.EndDate.Before(*employee.StartDate) {
+		return errors.New("end date is before start date")
+	}
+	if employee.WeeklyHours < 0 || employee.WeeklyHours > 80 {
+		return errors.New("weekly hours must be between 0 and 80")
+	}
+	if !employee.TeamID.IsZero() {
+		count, err := db.Collection("teams").CountDocuments(ctx, bson.M{"_id": employee.TeamID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("team not found")
+		}
+	}
+	if !employee.ManagerID.IsZero() {
+		if employee.ManagerID == employee.ID {
+			return errors.New("employee cannot manage themselves")
+		}
+		var manager Employee
+		err := db.Collection("employees").FindOne(ctx, bson.M{
+			"_id":       employee.ManagerID,
+			"deletedAt": bson.M{"$exists": false},
+		}).Decode(&manager)
+		if err != nil {
+			return errors.New("manager not found")
+		}
+		if manager.Type != "staff" {
+			return errors.New("manager must be a staff member")
+		}
+	}
+	return nil
+}
+
+// updateEmployee edits an employee's name, type and profile. A new name is
+// copied to the works and tasks in the background, since an employee may
+// have many.
+func updateEmployee(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		Name        string              `json:"name"`
+		Type        string              `json:"type"`
+		Email       *string             `json:"email"`
+		StartDate   *time.Time          `json:"startDate"`
+		EndDate     *time.Time          `json:"endDate"`
+		WeeklyHours *float64            `json:"weeklyHours"`
+		TeamID      *primitive.ObjectID `json:"teamId"`
+		ManagerID   *primitive.ObjectID `json:"managerId"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var employee Employee
+	err = db.Collection("employees").FindOne(ctx, bson.M{"_id": id, "anonymizedAt": bson.M{"$exists": false}}).Decode(&employee)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employee: " + err.Error()})
+	}
+
+	updateFields := bson.M{}
+	if update.Name != "" {
+		employee.Name = update.Name
+		updateFields["name"] = update.Name
+	}
+	if update.Type != "" {
+		if update.Type != "staff" && update.Type != "intern" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid employee type. Must be either 'staff' or 'intern'",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Geçersiz personel tipi. Personel veya Stajyer seçiniz.",
+			})
+		}
+		employee.Type = update.Type
+		updateFields["type"] = update.Type
+	}
+	if update.Email != nil {
+		employee.Email = *update.Email
+		updateFields["email"] = *update.Email
+	}
+	if update.StartDate != nil {
+		employee.StartDate = update.StartDate
+		updateFields["startDate"] = *update.StartDate
+	}
+	if update.EndDate != nil {
+		employee.EndDate = update.EndDate
+		updateFields["endDate"] = *update.EndDate
+	}
+	if update.WeeklyHours != nil {
+		employee.WeeklyHours = *update.WeeklyHours
+		updateFields["weeklyHours"] = *update.WeeklyHours
+	}
+	if update.TeamID != nil {
+		employee.TeamID = *update.TeamID
+		updateFields["teamId"] = *update.TeamID
+	}
+	if update.ManagerID != nil {
+		employee.ManagerID = *update.ManagerID
+		updateFields["managerId"] = *update.ManagerID
+	}
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Nothing to update"})
+	}
+
+	if err := validateEmployeeProfile(ctx, employee); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Personel bilgileri geçersiz: " + err.Error(),
+		})
+	}
+
+	_, err = db.Collection("employees").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update employee: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Personel/stajyer güncellenirken bir hata oluştu.",
+		})
+	}
+
+	if update.Name != "" {
+		go func(id primitive.ObjectID, name string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			if err := renameEmployeeCopies(ctx, id, name); err != nil {
+				log.Printf("Error propagating name of employee %s: %v", id.Hex(), err)
+			}
+		}(employee.ID, employee.Name)
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Personel/stajyer başarıyla güncellendi.",
+		"data":  employee,
+	})
+}
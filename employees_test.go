@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScrubValue(t *testing.T) {
+	replacer := anonymizeReplacer(Employee{Name: "Ayşe Yılmaz", Email: "ayse@example.com"}, "Anonim Personel 1a2b3c")
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"plain string", "Ayşe Yılmaz", "Anonim Personel 1a2b3c"},
+		{"inside text", "Ayşe Yılmaz videonuzu inceledi", "Anonim Personel 1a2b3c videonuzu inceledi"},
+		{"email", "ayse@example.com", ""},
+		{"other values", int32(5), int32(5)},
+		{
+			"nested document",
+			bson.M{"employeeName": "Ayşe Yılmaz", "reviews": primitive.A{bson.M{"reviewerName": "Ayşe Yılmaz", "comment": "iyi"}}},
+			bson.M{"employeeName": "Anonim Personel 1a2b3c", "reviews": primitive.A{bson.M{"reviewerName": "Anonim Personel 1a2b3c", "comment": "iyi"}}},
+		},
+		{
+			"work correction values",
+			primitive.A{
+				bson.M{"field": "employeeName", "before": "Ayşe Yılmaz", "after": "Ayşe Kaya"},
+				bson.M{"field": "reviews", "before": primitive.A{bson.M{"reviewerName": "Ayşe Yılmaz"}}, "after": primitive.A{}},
+				bson.M{"field": "revisedByName", "before": "", "after": "Ayşe Yılmaz"},
+			},
+			primitive.A{
+				bson.M{"field": "employeeName", "before": "Anonim Personel 1a2b3c", "after": "Ayşe Kaya"},
+				bson.M{"field": "reviews", "before": primitive.A{bson.M{"reviewerName": "Anonim Personel 1a2b3c"}}, "after": primitive.A{}},
+				bson.M{"field": "revisedByName", "before": "", "after": "Anonim Personel 1a2b3c"},
+			},
+		},
+		{
+			"work history set payload",
+			bson.M{"reviews.0.reviewerName": "Ayşe Yılmaz", "reviews.1": bson.M{"reviewerName": "Ayşe Yılmaz", "comment": "ayse@example.com"}},
+			bson.M{"reviews.0.reviewerName": "Anonim Personel 1a2b3c", "reviews.1": bson.M{"reviewerName": "Anonim Personel 1a2b3c", "comment": ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrubValue(tt.value, replacer); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scrubValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
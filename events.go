@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain events. Work and employee events are derived from MongoDB change
+// streams (see changestreams.go), task events are published by the handlers.
+const (
+	EventWorkCreated     = "work.created"
+	EventWorkUpdated     = "work.updated"
+	EventWorkCompleted   = "work.completed"
+	EventReviewSubmitted = "review.submitted"
+	EventVideoApproved   = "video.approved"
+	EventEmployeeDeleted = "employee.deleted"
+	EventEmployeeRenamed = "employee.renamed"
+	EventTaskAssigned    = "task.assigned"
+)
+
+var knownEvents = map[string]bool{
+	EventWorkCreated:     true,
+	EventWorkUpdated:     true,
+	EventWorkCompleted:   true,
+	EventReviewSubmitted: true,
+	EventVideoApproved:   true,
+	EventEmployeeDeleted: true,
+	EventEmployeeRenamed: true,
+	EventTaskAssigned:    true,
+}
+
+// Event is the envelope handed to subscribers and posted to webhooks.
+type Event struct {
+	ID        primitive.ObjectID `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      interface{}        `json:"data"`
+}
+
+// queuedEvent is an event waiting for a subscriber, or a flush marker when
+// flushed is set.
+type queuedEvent struct {
+	event   Event
+	flushed *sync.WaitGroup
+}
+
+// eventQueue holds the events a subscriber has not handled yet. Pushing never
+// blocks, so a slow subscriber cannot hold up publishing or the other
+// subscribers; the change stream still waits for every subscriber through
+// flushEvents before it moves on.
+type eventQueue struct {
+	mu      sync.Mutex
+	pending []queuedEvent
+	ready   chan struct{}
+}
+
+func (q *eventQueue) push(item queuedEvent) {
+	q.mu.Lock()
+	q.pending = append(q.pending, item)
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued items in order and empties the queue.
+func (q *eventQueue) take() []queuedEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := q.pending
+	q.pending = nil
+	return items
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   []*eventQueue
+)
+
+// subscribe registers a handler. Every handler runs in its own goroutine and
+// receives the events one at a time, in the order they were published.
+func subscribe(handler func(Event)) {
+	queue := &eventQueue{ready: make(chan struct{}, 1)}
+	go func() {
+		for range queue.ready {
+			for _, item := range queue.take() {
+				if item.flushed != nil {
+					item.flushed.Done()
+					continue
+				}
+				handler(item.event)
+			}
+		}
+	}()
+
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscribers = append(subscribers, queue)
+}
+
+// publishEvent queues an event for all subscribers. Publishing is serialised,
+// so every subscriber sees events in the same order.
+func publishEvent(eventType string, data interface{}) {
+	publishEventWithID(primitive.NewObjectID(), eventType, data)
+}
+
+// publishEventWithID publishes an event under a given ID. Events derived from
+// a change get the same ID when the change is replayed, so subscribers can
+// recognise duplicates.
+func publishEventWithID(id primitive.ObjectID, eventType string, data interface{}) {
+	event := Event{ID: id, Type: eventType, CreatedAt: time.Now(), Data: data}
+
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for _, queue := range subscribers {
+		queue.push(queuedEvent{event: event})
+	}
+}
+
+// flushEvents waits until every subscriber has handled the events published
+// before the call.
+func flushEvents() {
+	var flushed sync.WaitGroup
+	subscribersMu.Lock()
+	for _, queue := range subscribers {
+		flushed.Add(1)
+		queue.push(queuedEvent{flushed: &flushed})
+	}
+	subscribersMu.Unlock()
+	flushed.Wait()
+}
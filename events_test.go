@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPublishEventKeepsOrder(t *testing.T) {
+	const count = 500
+	received := make(chan string, count)
+	subscribe(func(event Event) {
+		if event.Type == "test.ordered" {
+			// A slow handler must not reorder the events that queue up behind it
+			time.Sleep(time.Microsecond)
+			received <- event.Data.(string)
+		}
+	})
+
+	for i := 0; i < count; i++ {
+		publishEvent("test.ordered", fmt.Sprint(i))
+	}
+	for i := 0; i < count; i++ {
+		select {
+		case got := <-received:
+			if want := fmt.Sprint(i); got != want {
+				t.Fatalf("event %d arrived as %s", i, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
+
+func TestFlushEventsWaitsForHandlers(t *testing.T) {
+	handled := 0
+	subscribe(func(event Event) {
+		if event.Type == "test.flush" {
+			time.Sleep(10 * time.Millisecond)
+			handled++
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		publishEvent("test.flush", nil)
+	}
+	flushEvents()
+	if handled != 3 {
+		t.Errorf("flushEvents returned after %d of 3 events were handled", handled)
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockPublishing(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	subscribe(func(event Event) {
+		if event.Type == "test.stuck" {
+			<-release
+		}
+	})
+	received := make(chan struct{}, 1)
+	subscribe(func(event Event) {
+		if event.Type == "test.after_stuck" {
+			received <- struct{}{}
+		}
+	})
+
+	// More events than a bounded queue would hold pile up behind the stuck handler
+	for i := 0; i < 1000; i++ {
+		publishEvent("test.stuck", nil)
+	}
+	publishEvent("test.after_stuck", nil)
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a stuck subscriber held up the other subscribers")
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Turkish date formats used in exported files
+const (
+	exportDateFormat     = "02.01.2006"
+	exportDateTimeFormat = "02.01.2006 15:04"
+)
+
+var workTypeLabels = map[string]string{
+	"software": "Yazılım",
+	"video":    "Video",
+	"review":   "İnceleme",
+	"revize":   "Revize",
+}
+
+var workStatusLabels = map[string]string{
+	"in_progress":      "Devam ediyor",
+	"paused":           "Duraklatıldı",
+	"completed":        "Tamamlandı",
+	"pending_approval": "Onay bekliyor",
+	"rejected":         "Reddedildi",
+}
+
+var turkishWeekdays = [...]string{"Pazar", "Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma", "Cumartesi"}
+
+var workExportHeader = []string{"Personel", "İş Türü", "Açıklama", "Video Linki", "Durum", "Başlangıç", "Bitiş", "Süre (dk)", "Süre", "Manuel Kayıt", "Silindi"}
+
+var timesheetExportHeader = []string{"Personel", "Tarih", "Gün", "Gün Türü", "İş Sayısı", "İlk Başlangıç", "Son Bitiş", "Çalışılan (dk)", "Çalışılan", "Beklenen (dk)"}
+
+// TimesheetDay is one row of an employee's timesheet.
+type TimesheetDay struct {
+	Date            time.Time
+	Kind            string
+	Works           int
+	FirstStart      time.Time
+	LastEnd         time.Time
+	Minutes         int
+	ExpectedMinutes int
+}
+
+func label(labels map[string]string, key string) string {
+	if value, ok := labels[key]; ok {
+		return value
+	}
+	return key
+}
+
+func formatExportTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Local).Format(layout)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Evet"
+	}
+	return "Hayır"
+}
+
+func workExportRow(work Work) []string {
+	return []string{
+		work.EmployeeName,
+		label(workTypeLabels, work.WorkType),
+		work.Description,
+		work.VideoLink,
+		label(workStatusLabels, work.Status),
+		formatExportTime(work.StartTime, exportDateTimeFormat),
+		formatExportTime(work.EndTime, exportDateTimeFormat),
+		strconv.Itoa(work.DurationMinutes),
+		formatDuration(work.DurationMinutes),
+		yesNo(work.IsManual),
+		yesNo(work.DeletedAt != nil),
+	}
+}
+
+func timesheetExportRow(employee Employee, day TimesheetDay) []string {
+	kinds := map[string]string{"workday": "İş günü", "weekend": "Hafta sonu", "holiday": "Tatil", "leave": "İzin"}
+	return []string{
+		employee.Name,
+		day.Date.Format(exportDateFormat),
+		turkishWeekdays[day.Date.Weekday()],
+		label(kinds, day.Kind),
+		strconv.Itoa(day.Works),
+		formatExportTime(day.FirstStart, "15:04"),
+		formatExportTime(day.LastEnd, "15:04"),
+		strconv.Itoa(day.Minutes),
+		formatDuration(day.Minutes),
+		strconv.Itoa(day.ExpectedMinutes),
+	}
+}
+
+// exportFormat reads the "format" query parameter and sets the download
+// headers for the file.
+func exportFormat(c *fiber.Ctx, name string) (string, error) {
+	format := c.Query("format", "csv")
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	switch format {
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	case "xlsx":
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		return "", fmt.Errorf("unsupported format %q, use csv or xlsx", format)
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return format, nil
+}
+
+// newCSVWriter writes a UTF-8 byte order mark so spreadsheet programs detect
+// the encoding of Turkish names, and separates columns with ';' as Excel
+// expects in the Turkish locale.
+func newCSVWriter(w io.Writer) (*csv.Writer, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	return writer, nil
+}
+
+// xlsxSheet streams rows into one sheet of a workbook.
+type xlsxSheet struct {
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXSheet(f *excelize.File, name string, header []string) (*xlsxSheet, error) {
+	if _, err := f.NewSheet(name); err != nil {
+		return nil, err
+	}
+	stream, err := f.NewStreamWriter(name)
+	if err != nil {
+		return nil, err
+	}
+	sheet := &xlsxSheet{stream: stream}
+	return sheet, sheet.write(header)
+}
+
+func (s *xlsxSheet) write(values []string) error {
+	s.row++
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, s.row)
+	if err != nil {
+		return err
+	}
+	return s.stream.SetRow(cell, cells)
+}
+
+// xlsxSheetInvalid replaces the characters Excel does not allow in sheet
+// names.
+var xlsxSheetInvalid = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_")
+
+// xlsxSheetName turns a name into a sheet name Excel accepts: invalid
+// characters replaced, at most 31 characters and unique in used, which Excel
+// compares case-insensitively. The returned name is added to used.
+func xlsxSheetName(name string, used map[string]bool) string {
+	base := []rune(strings.Trim(xlsxSheetInvalid.Replace(name), "'"))
+	if len(base) == 0 {
+		base = []rune("Sayfa")
+	}
+	sheet := string(base)
+	if len(base) > 31 {
+		sheet = string(base[:31])
+	}
+	for n := 2; used[strings.ToLower(sheet)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		if len(base)+len(suffix) > 31 {
+			sheet = string(base[:31-len(suffix)]) + suffix
+		} else {
+			sheet = string(base) + suffix
+		}
+	}
+	used[strings.ToLower(sheet)] = true
+	return sheet
+}
+
+// exportCell keeps spreadsheet programs from running a CSV value as a formula
+// by prefixing values that start like one with an apostrophe. XLSX cells are
+// written as strings and need no prefix.
+func exportCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeCSVRow writes a row of values guarded by exportCell.
+func writeCSVRow(writer *csv.Writer, values []string) error {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = exportCell(value)
+	}
+	return writer.Write(cells)
+}
+
+// exportWorks streams the works matching the listing filters as CSV or XLSX.
+func exportWorks(c *fiber.Ctx) error {
+	filter, err := worksFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	format, err := exportFormat(c, "isler")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("works").Find(ctx, filter, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	w := c.Response().BodyWriter()
+	if format == "csv" {
+		writer, err := newCSVWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := writer.Write(workExportHeader); err != nil {
+			return err
+		}
+		for cursor.Next(ctx) {
+			var work Work
+			if err := cursor.Decode(&work); err != nil {
+				return err
+			}
+			if err := writeCSVRow(writer, workExportRow(work)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		return cursor.Err()
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet, err := newXLSXSheet(f, "İşler", workExportHeader)
+	if err != nil {
+		return err
+	}
+	for cursor.Next(ctx) {
+		var work Work
+		if err := cursor.Decode(&work); err != nil {
+			return err
+		}
+		if err := sheet.write(workExportRow(work)); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := sheet.stream.Flush(); err != nil {
+		return err
+	}
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// employeeTimesheet totals an employee's works per day between from and to.
+func employeeTimesheet(ctx context.Context, employee Employee, from, to time.Time) ([]TimesheetDay, error) {
+	days, err := availability(ctx, employee, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": employee.ID,
+		"status":     "completed",
+		"startTime":  bson.M{"$gte": from, "$lte": to},
+		"deletedAt":  bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return nil, err
+	}
+	sort.Slice(works, func(i, j int) bool { return works[i].StartTime.Before(works[j].StartTime) })
+
+	index := map[string]int{}
+	timesheet := make([]TimesheetDay, len(days))
+	for i, day := range days {
+		date, _ := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		timesheet[i] = TimesheetDay{Date: date, Kind: day.Kind, ExpectedMinutes: day.ExpectedMinutes}
+		index[day.Date] = i
+	}
+	for _, work := range works {
+		i, ok := index[work.StartTime.In(time.Local).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		day := &timesheet[i]
+		day.Works++
+		day.Minutes += work.DurationMinutes
+		if day.FirstStart.IsZero() {
+			day.FirstStart = work.StartTime
+		}
+		if work.EndTime.After(day.LastEnd) {
+			day.LastEnd = work.EndTime
+		}
+	}
+	return timesheet, nil
+}
+
+// exportTimesheets writes a daily timesheet for one employee, or for every
+// active employee when no employeeId is given. XLSX files get a sheet per
+// employee.
+func exportTimesheets(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+	}
+	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter = bson.M{"_id": id}
+	}
+	format, err := exportFormat(c, "puantaj")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("employees").Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employees: " + err.Error()})
+	}
+	var employees []Employee
+	if err = cursor.All(ctx, &employees); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode employees: " + err.Error()})
+	}
+
+	w := c.Response().BodyWriter()
+	if format == "csv" {
+		writer, err := newCSVWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := writer.Write(timesheetExportHeader); err != nil {
+			return err
+		}
+		for _, employee := range employees {
+			timesheet, err := employeeTimesheet(ctx, employee, from, to)
+			if err != nil {
+				return err
+			}
+			for _, day := range timesheet {
+				if err := writeCSVRow(writer, timesheetExportRow(employee, day)); err != nil {
+					return err
+				}
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	// Sheet1 is only deleted at the end
+	used := map[string]bool{"sheet1": true}
+	for _, employee := range employees {
+		timesheet, err := employeeTimesheet(ctx, employee, from, to)
+		if err != nil {
+			return err
+		}
+		sheet, err := newXLSXSheet(f, xlsxSheetName(employee.Name, used), timesheetExportHeader)
+		if err != nil {
+			return err
+		}
+		for _, day := range timesheet {
+			if err := sheet.write(timesheetExportRow(employee, day)); err != nil {
+				return err
+			}
+		}
+		if err := sheet.stream.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(employees) > 0 {
+		if err := f.DeleteSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestXLSXSheetName(t *testing.T) {
+	long := strings.Repeat("Ş", 40)
+
+	tests := []struct {
+		name  string
+		used  []string
+		input string
+		want  string
+	}{
+		{"plain name", nil, "Ayşe Yılmaz", "Ayşe Yılmaz"},
+		{"invalid characters", nil, "A/B: [C]? *D\\", "A_B_ _C__ _D_"},
+		{"surrounding apostrophes", nil, "'Ali'", "Ali"},
+		{"empty name", nil, "", "Sayfa"},
+		{"truncated to 31 characters", nil, long, strings.Repeat("Ş", 31)},
+		{"duplicate gets a suffix", []string{"ayşe yılmaz"}, "Ayşe Yılmaz", "Ayşe Yılmaz (2)"},
+		{"duplicate compared case-insensitively", []string{"ali"}, "ALI", "ALI (2)"},
+		{"reserved default sheet", []string{"sheet1"}, "Sheet1", "Sheet1 (2)"},
+		{"next free suffix", []string{"ali", "ali (2)"}, "Ali", "Ali (3)"},
+		{"long duplicate keeps the suffix", []string{strings.ToLower(strings.Repeat("Ş", 31))}, long, strings.Repeat("Ş", 27) + " (2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := map[string]bool{}
+			for _, name := range tt.used {
+				used[name] = true
+			}
+			got := xlsxSheetName(tt.input, used)
+			if got != tt.want {
+				t.Errorf("xlsxSheetName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > 31 {
+				t.Errorf("xlsxSheetName(%q) = %q, longer than 31 characters", tt.input, got)
+			}
+			if !used[strings.ToLower(got)] {
+				t.Errorf("xlsxSheetName(%q) did not mark %q as used", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestXLSXSheetNameManyDuplicates(t *testing.T) {
+	used := map[string]bool{}
+	long := strings.Repeat("a", 40)
+	for i := 0; i < 120; i++ {
+		name := xlsxSheetName(long, used)
+		if utf8.RuneCountInString(name) > 31 {
+			t.Fatalf("sheet %d: %q is longer than 31 characters", i, name)
+		}
+	}
+	if len(used) != 120 {
+		t.Errorf("got %d distinct sheet names, want 120", len(used))
+	}
+}
+
+func TestExportCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Ayşe", "Ayşe"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+90 555", "'+90 555"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := exportCell(tt.value); got != tt.want {
+			t.Errorf("exportCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestExportRowsGuardOnlyCSV(t *testing.T) {
+	work := Work{EmployeeName: "=Ayşe", Description: "-5 dakika", Status: "completed"}
+
+	// XLSX cells get the values as they are
+	row := workExportRow(work)
+	if row[0] != "=Ayşe" || row[2] != "-5 dakika" {
+		t.Errorf("workExportRow() = %q, want the raw values", row)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = ';'
+	if err := writeCSVRow(writer, row); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+	if got := buf.String(); !strings.HasPrefix(got, "'=Ayşe;;'-5 dakika;") {
+		t.Errorf("writeCSVRow() wrote %q", got)
+	}
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportRows caps the size of a single import request.
+const maxImportRows = 5000
+
+var importWorkTypes = map[string]bool{"software": true, "video": true, "review": true, "revize": true}
+
+// ImportError describes why one row of an import was rejected. Rows are
+// numbered as the user sees them: CSV rows count the header line, JSON rows
+// start at 1.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportOverlap is an imported work that overlaps another row of the file or
+// a work already stored for the same employee. Overlaps do not stop an
+// import, the works are marked with hasOverlap instead.
+type ImportOverlap struct {
+	Row     int                 `json:"row"`
+	WithRow int                 `json:"withRow,omitempty"`
+	WorkID  *primitive.ObjectID `json:"workId,omitempty"`
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	Minutes int                 `json:"minutes"`
+}
+
+type ImportResult struct {
+	DryRun   bool            `json:"dryRun"`
+	Total    int             `json:"total"`
+	Valid    int             `json:"valid"`
+	Imported int             `json:"imported"`
+	Errors   []ImportError   `json:"errors"`
+	Overlaps []ImportOverlap `json:"overlaps,omitempty"`
+}
+
+// importRows reads the request body as a JSON array of objects or as CSV,
+// either raw or uploaded as the "file" form field. Column names are matched
+// case-insensitively. The returned offset turns a slice index into the row
+// number reported to the user.
+func importRows(c *fiber.Ctx) ([]map[string]string, int, error) {
+	body := c.Body()
+	isJSON := strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON)
+	if file, err := c.FormFile("file"); err == nil {
+		isJSON = strings.HasSuffix(strings.ToLower(file.Filename), ".json")
+		f, err := file.Open()
+		if err != nil {
+			return nil, 0, err
+		}
+		defer f.Close()
+		if body, err = io.ReadAll(f); err != nil {
+			return nil, 0, err
+		}
+	}
+	body = bytes.TrimPrefix(body, []byte("\xEF\xBB\xBF"))
+
+	if isJSON {
+		var items []map[string]interface{}
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, 0, fmt.Errorf("invalid JSON: %w", err)
+		}
+		rows := make([]map[string]string, len(items))
+		for i, item := range items {
+			rows[i] = map[string]string{}
+			for key, value := range item {
+				if value != nil {
+					rows[i][strings.ToLower(key)] = strings.TrimSpace(fmt.Sprint(value))
+				}
+			}
+		}
+		return rows, 1, nil
+	}
+
+	// Files from our own export use ';', most other tools use ','
+	reader := csv.NewReader(bytes.NewReader(body))
+	if firstLine, _, _ := bytes.Cut(body, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, 0, errors.New("file is empty")
+	}
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				row[strings.ToLower(strings.TrimSpace(header[i]))] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, 2, nil
+}
+
+// parseImportTime accepts RFC 3339 timestamps as well as local
+// "2006-01-02 15:04" and the "02.01.2006 15:04" format used by the exports.
+func parseImportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", exportDateTimeFormat} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unparsable time %q", value)
+}
+
+func parseImportDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("unparsable date %q, use YYYY-MM-DD", value)
+	}
+	return &t, nil
+}
+
+func parseImportID(value string) (primitive.ObjectID, error) {
+	if value == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid ID %q", value)
+	}
+	return id, nil
+}
+
+// finishImport inserts the documents unless the request is a dry run or any
+// row failed validation, in which case nothing is written.
+func finishImport(ctx context.Context, c *fiber.Ctx, collection string, docs []interface{}, result ImportResult) error {
+	result.Valid = len(docs)
+	if len(result.Errors) > 0 {
+		status := fiber.StatusUnprocessableEntity
+		if result.DryRun {
+			status = fiber.StatusOK
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  fmt.Sprintf("%d satırda hata bulundu, hiçbir kayıt içe aktarılmadı.", len(result.Errors)),
+			"data":  result,
+		})
+	}
+	if result.DryRun || len(docs) == 0 {
+		return c.JSON(fiber.Map{
+			"type": "success",
+			"text": fmt.Sprintf("%d satır içe aktarılmaya hazır.", result.Valid),
+			"data": result,
+		})
+	}
+
+	inserted, err := db.Collection(collection).InsertMany(ctx, docs)
+	if inserted != nil {
+		result.Imported = len(inserted.InsertedIDs)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import " + collection + ": " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "İçe aktarma sırasında bir hata oluştu.",
+			"data":  result,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  fmt.Sprintf("%d kayıt içe aktarıldı.", result.Imported),
+		"data":  result,
+	})
+}
+
+// importKeys remembers the row each key was first seen in, so rows repeated
+// within one file are reported instead of being imported twice.
+type importKeys map[string]int
+
+// seen records key for row and returns the earlier row with the same key.
+func (k importKeys) seen(key string, row int) (int, bool) {
+	if first, ok := k[key]; ok {
+		return first, true
+	}
+	k[key] = row
+	return 0, false
+}
+
+func readImport(c *fiber.Ctx) ([]map[string]string, int, ImportResult, error) {
+	rows, offset, err := importRows(c)
+	if err != nil {
+		return nil, 0, ImportResult{}, err
+	}
+	if len(rows) > maxImportRows {
+		return nil, 0, ImportResult{}, fmt.Errorf("at most %d rows can be imported at once", maxImportRows)
+	}
+	result := ImportResult{
+		DryRun: c.QueryBool("dryRun"),
+		Total:  len(rows),
+		Errors: []ImportError{},
+	}
+	return rows, offset, result, nil
+}
+
+// importEmployees creates employees in bulk. Columns: name, type, email,
+// startDate, endDate, weeklyHours, teamId, managerId.
+func importEmployees(c *fiber.Ctx) error {
+	rows, offset, result, err := readImport(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	docs := []interface{}{}
+	keys := importKeys{}
+	for i, row := range rows {
+		rowErrors := len(result.Errors)
+		fail := func(field string, err error) {
+			result.Errors = append(result.Errors, ImportError{Row: i + offset, Field: field, Error: err.Error()})
+		}
+
+		employee := Employee{ID: primitive.NewObjectID(), Name: row["name"], Type: row["type"]}
+		if employee.Name == "" {
+			fail("name", errors.New("name is required"))
+		}
+		if employee.Type != "staff" && employee.Type != "intern" {
+			fail("type", fmt.Errorf("invalid type %q, must be 'staff' or 'intern'", employee.Type))
+		}
+		employee.Email = row["email"]
+		if employee.StartDate, err = parseImportDate(row["startdate"]); err != nil {
+			fail("startDate", err)
+		}
+		if employee.EndDate, err = parseImportDate(row["enddate"]); err != nil {
+			fail("endDate", err)
+		}
+		if value := row["weeklyhours"]; value != "" {
+			if employee.WeeklyHours, err = strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64); err != nil {
+				fail("weeklyHours", fmt.Errorf("invalid number %q", value))
+			}
+		}
+		if employee.TeamID, err = parseImportID(row["teamid"]); err != nil {
+			fail("teamId", err)
+		}
+		if employee.ManagerID, err = parseImportID(row["managerid"]); err != nil {
+			fail("managerId", err)
+		}
+		if len(result.Errors) > rowErrors {
+			continue
+		}
+		if err := validateEmployeeProfile(ctx, employee); err != nil {
+			fail("", err)
+			continue
+		}
+		email := strings.ToLower(employee.Email)
+		if first, ok := keys.seen("name:"+strings.ToLower(employee.Name)+"|"+email, i+offset); ok {
+			fail("", fmt.Errorf("duplicate of row %d", first))
+			continue
+		}
+		if first, ok := keys.seen("email:"+email, i+offset); ok && email != "" {
+			fail("email", fmt.Errorf("email address already used in row %d", first))
+			continue
+		}
+		docs = append(docs, employee)
+	}
+
+	return finishImport(ctx, c, "employees", docs, result)
+}
+
+// importWorks creates completed historical works in bulk. Columns:
+// employeeId or employeeName, workType, description, videoLink, startTime,
+// endTime.
+func importWorks(c *fiber.Ctx) error {
+	rows, offset, result, err := readImport(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("employees").Find(ctx, bson.M{"deletedAt": bson.M{"$exists": false}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employees: " + err.Error()})
+	}
+	var employees []Employee
+	if err = cursor.All(ctx, &employees); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode employees: " + err.Error()})
+	}
+	byID := map[primitive.ObjectID]Employee{}
+	byName := map[string][]Employee{}
+	for _, employee := range employees {
+		byID[employee.ID] = employee
+		key := strings.ToLower(employee.Name)
+		byName[key] = append(byName[key], employee)
+	}
+
+	var works []Work
+	var workRows []int
+	keys := importKeys{}
+	for i, row := range rows {
+		rowErrors := len(result.Errors)
+		fail := func(field string, err error) {
+			result.Errors = append(result.Errors, ImportError{Row: i + offset, Field: field, Error: err.Error()})
+		}
+
+		var employee Employee
+		if value := row["employeeid"]; value != "" {
+			id, err := parseImportID(value)
+			if err != nil {
+				fail("employeeId", err)
+			} else if e, ok := byID[id]; ok {
+				employee = e
+			} else {
+				fail("employeeId", fmt.Errorf("unknown employee %q", value))
+			}
+		} else if value := row["employeename"]; value != "" {
+			switch matches := byName[strings.ToLower(value)]; len(matches) {
+			case 0:
+				fail("employeeName", fmt.Errorf("unknown employee %q", value))
+			case 1:
+				employee = matches[0]
+			default:
+				fail("employeeName", fmt.Errorf("employee name %q is ambiguous, use employeeId", value))
+			}
+		} else {
+			fail("employeeId", errors.New("employeeId or employeeName is required"))
+		}
+
+		work := Work{
+			ID:          primitive.NewObjectID(),
+			EmployeeID:  employee.ID,
+			WorkType:    row["worktype"],
+			Description: row["description"],
+			VideoLink:   row["videolink"],
+			Status:      "completed",
+			Reviews:     []Review{},
+		}
+		if !importWorkTypes[work.WorkType] {
+			fail("workType", fmt.Errorf("invalid work type %q", work.WorkType))
+		}
+		if err := validateVideoLink(work.VideoLink); err != nil {
+			fail("videoLink", err)
+		}
+		if work.StartTime, err = parseImportTime(row["starttime"]); err != nil {
+			fail("startTime", err)
+		}
+		if work.EndTime, err = parseImportTime(row["endtime"]); err != nil {
+			fail("endTime", err)
+		}
+		if len(result.Errors) > rowErrors {
+			continue
+		}
+		if !work.EndTime.After(work.StartTime) {
+			fail("endTime", errors.New("end time must be after start time"))
+			continue
+		}
+		if work.EndTime.After(time.Now()) {
+			fail("endTime", errors.New("historical works cannot end in the future"))
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%d|%d", work.EmployeeID.Hex(), work.WorkType, work.StartTime.Unix(), work.EndTime.Unix())
+		if first, ok := keys.seen(key, i+offset); ok {
+			fail("", fmt.Errorf("duplicate of row %d", first))
+			continue
+		}
+
+		duration := work.EndTime.Sub(work.StartTime)
+		work.EmployeeName = employee.Name
+		work.Duration = duration.String()
+		work.DurationMinutes = int(duration.Minutes())
+		works = append(works, work)
+		workRows = append(workRows, i+offset)
+	}
+
+	existing, err := storedWorksAround(ctx, works)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check overlapping works: " + err.Error()})
+	}
+	result.Overlaps = importOverlaps(works, workRows, existing, time.Now())
+
+	docs := make([]interface{}, len(works))
+	for i, work := range works {
+		docs[i] = work
+	}
+	return finishImport(ctx, c, "works", docs, result)
+}
+
+// storedWorksAround loads the stored works of the imported works' employees
+// that may overlap them.
+func storedWorksAround(ctx context.Context, works []Work) ([]Work, error) {
+	if len(works) == 0 {
+		return nil, nil
+	}
+	employeeIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	from, to := works[0].StartTime, works[0].EndTime
+	for _, work := range works {
+		if !seen[work.EmployeeID] {
+			seen[work.EmployeeID] = true
+			employeeIDs = append(employeeIDs, work.EmployeeID)
+		}
+		from = minTime(from, work.StartTime)
+		to = maxTime(to, work.EndTime)
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": bson.M{"$in": employeeIDs},
+		"startTime":  bson.M{"$lt": to},
+		"status":     bson.M{"$nin": []string{"rejected", "pending_approval"}},
+		"deletedAt":  bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"endTime": bson.M{"$gt": from}},
+			{"endTime": bson.M{"$exists": false}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var existing []Work
+	err = cursor.All(ctx, &existing)
+	return existing, err
+}
+
+// importOverlaps finds the imported works that overlap each other or a stored
+// work of the same employee and marks them with HasOverlap. rows holds the
+// row number of each imported work.
+func importOverlaps(works []Work, rows []int, existing []Work, now time.Time) []ImportOverlap {
+	overlaps := []ImportOverlap{}
+	add := func(i int, shared timeRange, withRow int, workID *primitive.ObjectID) {
+		works[i].HasOverlap = true
+		overlaps = append(overlaps, ImportOverlap{
+			Row:     rows[i],
+			WithRow: withRow,
+			WorkID:  workID,
+			Start:   shared.Start,
+			End:     shared.End,
+			Minutes: int(shared.End.Sub(shared.Start).Minutes()),
+		})
+	}
+
+	for i := range works {
+		interval := []timeRange{{Start: works[i].StartTime, End: works[i].EndTime}}
+		for j := i + 1; j < len(works); j++ {
+			if works[j].EmployeeID != works[i].EmployeeID {
+				continue
+			}
+			other := []timeRange{{Start: works[j].StartTime, End: works[j].EndTime}}
+			for _, shared := range intersectIntervals(interval, other) {
+				add(i, shared, rows[j], nil)
+				works[j].HasOverlap = true
+			}
+		}
+		for _, stored := range existing {
+			if stored.EmployeeID != works[i].EmployeeID {
+				continue
+			}
+			id := stored.ID
+			for _, shared := range intersectIntervals(interval, activeIntervals(stored, now)) {
+				add(i, shared, 0, &id)
+			}
+		}
+	}
+	return overlaps
+}
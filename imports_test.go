@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseImportTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC 3339 UTC", "2024-05-06T09:30:00Z", time.Date(2024, 5, 6, 9, 30, 0, 0, time.UTC), false},
+		{"RFC 3339 with offset", "2024-05-06T12:30:00+03:00", time.Date(2024, 5, 6, 9, 30, 0, 0, time.UTC), false},
+		{"local with space", "2024-05-06 09:30", time.Date(2024, 5, 6, 9, 30, 0, 0, time.Local), false},
+		{"local with T", "2024-05-06T09:30", time.Date(2024, 5, 6, 9, 30, 0, 0, time.Local), false},
+		{"export format", "06.05.2024 09:30", time.Date(2024, 5, 6, 9, 30, 0, 0, time.Local), false},
+		{"date only", "2024-05-06", time.Time{}, true},
+		{"invalid hour", "2024-05-06 25:00", time.Time{}, true},
+		{"empty", "", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportTime(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportTime(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseImportTime(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	may6 := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    *time.Time
+		wantErr bool
+	}{
+		{"empty means no date", "", nil, false},
+		{"ISO date", "2024-05-06", &may6, false},
+		{"export format", "06.05.2024", nil, true},
+		{"invalid day", "2024-02-30", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportDate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("parseImportDate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportOverlaps(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 5, 6, hour, 0, 0, 0, time.UTC) }
+	ayse, ali := primitive.ObjectID{1}, primitive.ObjectID{2}
+	stored := Work{ID: primitive.ObjectID{9}, EmployeeID: ayse, StartTime: at(14), EndTime: at(16)}
+
+	works := []Work{
+		{EmployeeID: ayse, StartTime: at(9), EndTime: at(11)},
+		{EmployeeID: ayse, StartTime: at(10), EndTime: at(12)}, // Overlaps row 2
+		{EmployeeID: ali, StartTime: at(10), EndTime: at(12)},  // Other employee
+		{EmployeeID: ayse, StartTime: at(12), EndTime: at(13)}, // Touches row 3 only
+		{EmployeeID: ayse, StartTime: at(15), EndTime: at(17)}, // Overlaps the stored work
+	}
+	rows := []int{2, 3, 4, 5, 6}
+
+	overlaps := importOverlaps(works, rows, []Work{stored}, at(18))
+	if len(overlaps) != 2 {
+		t.Fatalf("got %d overlaps, want 2: %+v", len(overlaps), overlaps)
+	}
+	if o := overlaps[0]; o.Row != 2 || o.WithRow != 3 || o.WorkID != nil || o.Minutes != 60 {
+		t.Errorf("first overlap = %+v, want row 2 with row 3 for 60 minutes", o)
+	}
+	if o := overlaps[1]; o.Row != 6 || o.WorkID == nil || *o.WorkID != stored.ID || o.Minutes != 60 {
+		t.Errorf("second overlap = %+v, want row 6 with the stored work for 60 minutes", o)
+	}
+
+	want := []bool{true, true, false, false, true}
+	for i, work := range works {
+		if work.HasOverlap != want[i] {
+			t.Errorf("row %d HasOverlap = %v, want %v", rows[i], work.HasOverlap, want[i])
+		}
+	}
+}
+
+func TestImportOverlapsSkipsStoredPauses(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 5, 6, hour, 0, 0, 0, time.UTC) }
+	employee := primitive.ObjectID{1}
+	stored := Work{
+		ID:         primitive.ObjectID{9},
+		EmployeeID: employee,
+		StartTime:  at(9),
+		EndTime:    at(17),
+		Pauses:     []PauseInterval{{Start: at(12), End: at(13)}},
+	}
+	works := []Work{{EmployeeID: employee, StartTime: at(12), EndTime: at(13)}}
+
+	if overlaps := importOverlaps(works, []int{2}, []Work{stored}, at(18)); len(overlaps) != 0 {
+		t.Errorf("got overlaps %+v, want none inside a pause", overlaps)
+	}
+	if works[0].HasOverlap {
+		t.Error("work inside a pause was marked as overlapping")
+	}
+}
+
+func TestImportKeys(t *testing.T) {
+	keys := importKeys{}
+	steps := []struct {
+		key       string
+		row       int
+		wantFirst int
+		wantSeen  bool
+	}{
+		{"a", 2, 0, false},
+		{"b", 3, 0, false},
+		{"a", 4, 2, true},
+		{"a", 5, 2, true},
+		{"c", 6, 0, false},
+	}
+	for _, step := range steps {
+		first, seen := keys.seen(step.key, step.row)
+		if first != step.wantFirst || seen != step.wantSeen {
+			t.Errorf("seen(%q, %d) = %d, %v, want %d, %v", step.key, step.row, first, seen, step.wantFirst, step.wantSeen)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Internship struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EmployeeID   primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	EmployeeName string             `json:"employeeName" bson:"employeeName"`
+	MentorID     primitive.ObjectID `json:"mentorId" bson:"mentorId"`
+	MentorName   string             `json:"mentorName" bson:"mentorName"`
+	School       string             `json:"school,omitempty" bson:"school,omitempty"`
+	StartDate    time.Time          `json:"startDate" bson:"startDate"`
+	EndDate      time.Time          `json:"endDate" bson:"endDate"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type EvaluationScore struct {
+	Criterion string `json:"criterion" bson:"criterion"` // e.g. "Teknik beceri", "İletişim"
+	Score     int    `json:"score" bson:"score"`         // 1 to 5
+}
+
+// Evaluation is a periodic evaluation form filled in by the intern's mentor.
+type Evaluation struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	InternshipID primitive.ObjectID `json:"internshipId" bson:"internshipId"`
+	EmployeeID   primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	MentorID     primitive.ObjectID `json:"mentorId" bson:"mentorId"`
+	MentorName   string             `json:"mentorName" bson:"mentorName"`
+	PeriodStart  time.Time          `json:"periodStart" bson:"periodStart"`
+	PeriodEnd    time.Time          `json:"periodEnd" bson:"periodEnd"`
+	Scores       []EvaluationScore  `json:"scores" bson:"scores"`
+	Comment      string             `json:"comment" bson:"comment"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type InternshipReport struct {
+	Internship        Internship   `json:"internship"`
+	Evaluations       []Evaluation `json:"evaluations"`
+	AverageScore      float64      `json:"averageScore"`
+	WorkStats         WorkStats    `json:"workStats"`
+	TotalMinutes      int          `json:"totalMinutes"`
+	CompletedVideos   int          `json:"completedVideos"`
+	ApprovedVideos    int          `json:"approvedVideos"`
+	VideoApprovalRate int          `json:"videoApprovalRate"` // Percentage of completed videos approved
+	DaysCompleted     int          `json:"daysCompleted"`
+	DaysTotal         int          `json:"daysTotal"`
+}
+
+func createInternship(c *fiber.Ctx) error {
+	var internship Internship
+	if err := c.BodyParser(&internship); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if internship.StartDate.IsZero() || internship.EndDate.IsZero() || internship.EndDate.Before(internship.StartDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A valid internship start and end date are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen geçerli staj başlangıç ve bitiş tarihleri giriniz.",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var intern Employee
+	err := db.Collection("employees").FindOne(ctx, bson.M{
+		"_id":       internship.EmployeeID,
+		"deletedAt": bson.M{"$exists": false},
+	}).Decode(&intern)
+	if err != nil || intern.Type != "intern" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Intern not found",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Staj kaydı yalnızca stajyerler için oluşturulabilir.",
+		})
+	}
+
+	// The supervising staff member is the default mentor
+	if internship.MentorID.IsZero() {
+		internship.MentorID = intern.ManagerID
+	}
+	var mentor Employee
+	err = db.Collection("employees").FindOne(ctx, bson.M{
+		"_id":       internship.MentorID,
+		"deletedAt": bson.M{"$exists": false},
+	}).Decode(&mentor)
+	if err != nil || mentor.Type != "staff" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Mentor not found",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen personeller arasından bir mentor seçiniz.",
+		})
+	}
+
+	count, err := db.Collection("internships").CountDocuments(ctx, bson.M{"employeeId": intern.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check internships: " + err.Error()})
+	}
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Intern already has an internship record",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bu stajyer için zaten bir staj kaydı var.",
+		})
+	}
+
+	internship.ID = primitive.NewObjectID()
+	internship.EmployeeName = intern.Name
+	internship.MentorName = mentor.Name
+	internship.CreatedAt = time.Now()
+
+	if _, err := db.Collection("internships").InsertOne(ctx, internship); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create internship: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Staj kaydı oluşturulurken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Staj kaydı oluşturuldu.",
+		"data":  internship,
+	})
+}
+
+func getInternships(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if mentorID := c.Query("mentorId"); mentorID != "" {
+		id, err := primitive.ObjectIDFromHex(mentorID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid mentor ID format"})
+		}
+		filter["mentorId"] = id
+	}
+	if c.Query("active") == "true" {
+		now := time.Now()
+		filter["startDate"] = bson.M{"$lte": now}
+		filter["endDate"] = bson.M{"$gte": now}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("internships").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch internships: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	internships := []Internship{}
+	if err = cursor.All(ctx, &internships); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode internships: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": internships,
+	})
+}
+
+func updateInternship(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		MentorID  primitive.ObjectID `json:"mentorId"`
+		School    *string            `json:"school"`
+		StartDate time.Time          `json:"startDate"`
+		EndDate   time.Time          `json:"endDate"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var internship Internship
+	err = db.Collection("internships").FindOne(ctx, bson.M{"_id": id}).Decode(&internship)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Internship not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch internship: " + err.Error()})
+	}
+
+	updateFields := bson.M{}
+	if !update.MentorID.IsZero() {
+		var mentor Employee
+		err := db.Collection("employees").FindOne(ctx, bson.M{
+			"_id":       update.MentorID,
+			"deletedAt": bson.M{"$exists": false},
+		}).Decode(&mentor)
+		if err != nil || mentor.Type != "staff" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Mentor not found",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Lütfen personeller arasından bir mentor seçiniz.",
+			})
+		}
+		updateFields["mentorId"] = mentor.ID
+		updateFields["mentorName"] = mentor.Name
+	}
+	if update.School != nil {
+		updateFields["school"] = *update.School
+	}
+	if !update.StartDate.IsZero() {
+		internship.StartDate = update.StartDate
+		updateFields["startDate"] = update.StartDate
+	}
+	if !update.EndDate.IsZero() {
+		internship.EndDate = update.EndDate
+		updateFields["endDate"] = update.EndDate
+	}
+	if internship.EndDate.Before(internship.StartDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "End date is before start date",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bitiş tarihi başlangıç tarihinden önce olamaz.",
+		})
+	}
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Nothing to update"})
+	}
+
+	_, err = db.Collection("internships").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update internship: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Internship updated successfully"})
+}
+
+// createEvaluation stores an evaluation form. Only the intern's current
+// mentor can evaluate them.
+func createEvaluation(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var evaluation Evaluation
+	if err := c.BodyParser(&evaluation); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if len(evaluation.Scores) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one score is required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen en az bir kriter puanlayınız.",
+		})
+	}
+	for _, score := range evaluation.Scores {
+		if score.Criterion == "" || score.Score < 1 || score.Score > 5 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Scores must have a criterion and be between 1 and 5",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Puanlar 1 ile 5 arasında olmalıdır.",
+			})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var internship Internship
+	err = db.Collection("internships").FindOne(ctx, bson.M{"_id": id}).Decode(&internship)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Internship not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch internship: " + err.Error()})
+	}
+	// The mentor is the caller, an admin may record the evaluation for them
+	if !isAdminToken(c.Get("X-Admin-Token")) {
+		callerID, err := primitive.ObjectIDFromHex(c.Get("X-Employee-Id"))
+		if err != nil || callerID != internship.MentorID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only the intern's mentor can evaluate",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Değerlendirmeyi yalnızca stajyerin mentoru yapabilir.",
+			})
+		}
+	}
+
+	if evaluation.PeriodEnd.IsZero() {
+		evaluation.PeriodEnd = time.Now()
+	}
+	if evaluation.PeriodStart.IsZero() {
+		evaluation.PeriodStart = internship.StartDate
+	}
+
+	evaluation.ID = primitive.NewObjectID()
+	evaluation.InternshipID = internship.ID
+	evaluation.EmployeeID = internship.EmployeeID
+	evaluation.MentorID = internship.MentorID
+	evaluation.MentorName = internship.MentorName
+	evaluation.CreatedAt = time.Now()
+
+	if _, err := db.Collection("evaluations").InsertOne(ctx, evaluation); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create evaluation: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Değerlendirme kaydedilirken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Değerlendirme kaydedildi.",
+		"data":  evaluation,
+	})
+}
+
+func findEvaluations(ctx context.Context, internshipID primitive.ObjectID) ([]Evaluation, error) {
+	cursor, err := db.Collection("evaluations").Find(
+		ctx,
+		bson.M{"internshipId": internshipID},
+		options.Find().SetSort(bson.M{"periodEnd": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	evaluations := []Evaluation{}
+	if err = cursor.All(ctx, &evaluations); err != nil {
+		return nil, err
+	}
+	return evaluations, nil
+}
+
+func getEvaluations(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	evaluations, err := findEvaluations(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch evaluations: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": evaluations,
+	})
+}
+
+// getInternshipReport combines the internship, its evaluations and the
+// intern's work stats and video approval rate into a progress report.
+func getInternshipReport(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var internship Internship
+	err = db.Collection("internships").FindOne(ctx, bson.M{"_id": id}).Decode(&internship)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Internship not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch internship: " + err.Error()})
+	}
+
+	evaluations, err := findEvaluations(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch evaluations: " + err.Error()})
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": internship.EmployeeID,
+		"status":     "completed",
+		"deletedAt":  bson.M{"$exists": false},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works"})
+	}
+	defer cursor.Close(ctx)
+
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works"})
+	}
+
+	report := InternshipReport{
+		Internship:  internship,
+		Evaluations: evaluations,
+		WorkStats:   workStats(works),
+	}
+
+	var scoreTotal, scoreCount int
+	for _, evaluation := range evaluations {
+		for _, score := range evaluation.Scores {
+			scoreTotal += score.Score
+			scoreCount++
+		}
+	}
+	if scoreCount > 0 {
+		report.AverageScore = float64(scoreTotal) / float64(scoreCount)
+	}
+
+	for _, work := range works {
+		report.TotalMinutes += work.DurationMinutes
+		if work.WorkType == "video" {
+			report.CompletedVideos++
+			if work.RevisionStatus == "approved" {
+				report.ApprovedVideos++
+			}
+		}
+	}
+	if report.CompletedVideos > 0 {
+		report.VideoApprovalRate = report.ApprovedVideos * 100 / report.CompletedVideos
+	}
+
+	report.DaysTotal, report.DaysCompleted = internshipDays(internship.StartDate, internship.EndDate, time.Now())
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": report,
+	})
+}
+
+// internshipDays returns the length of an internship in days and how many of
+// them have started by now. Nothing is completed before the start date.
+func internshipDays(start, end, now time.Time) (int, int) {
+	total := int(end.Sub(start).Hours()/24) + 1
+	if now.Before(start) {
+		return total, 0
+	}
+	completed := int(now.Sub(start).Hours()/24) + 1
+	if completed > total {
+		completed = total
+	}
+	return total, completed
+}
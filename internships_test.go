@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInternshipDays(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		wantCompleted int
+	}{
+		{"a day before the start", start.Add(-24 * time.Hour), 0},
+		{"an hour before the start", start.Add(-time.Hour), 0},
+		{"first day", start.Add(10 * time.Hour), 1},
+		{"tenth day", start.AddDate(0, 0, 9).Add(time.Hour), 10},
+		{"after the end", end.AddDate(0, 0, 5), 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, completed := internshipDays(start, end, tt.now)
+			if total != 31 || completed != tt.wantCompleted {
+				t.Errorf("internshipDays() = %d, %d, want 31, %d", total, completed, tt.wantCompleted)
+			}
+		})
+	}
+}
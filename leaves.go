@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultWeeklyHours is used for employees without contracted hours.
+const defaultWeeklyHours = 40
+
+type Leave struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EmployeeID   primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	EmployeeName string             `json:"employeeName" bson:"employeeName"`
+	Type         string             `json:"type" bson:"type"`           // "annual", "sick" or "unpaid"
+	StartDate    time.Time          `json:"startDate" bson:"startDate"` // First day of leave
+	EndDate      time.Time          `json:"endDate" bson:"endDate"`     // Last day of leave, inclusive
+	Reason       string             `json:"reason" bson:"reason"`
+	Status       string             `json:"status" bson:"status"` // "pending", "approved" or "rejected"
+	DecisionNote string             `json:"decisionNote,omitempty" bson:"decisionNote,omitempty"`
+	DecidedAt    *time.Time         `json:"decidedAt,omitempty" bson:"decidedAt,omitempty"`
+	DecidedBy    string             `json:"decidedBy,omitempty" bson:"decidedBy,omitempty"` // Admin who approved or rejected the leave
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type Holiday struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Date time.Time          `json:"date" bson:"date"`
+	Name string             `json:"name" bson:"name"`
+}
+
+// DayAvailability describes whether an employee was expected to work on a day.
+type DayAvailability struct {
+	Date            string `json:"date"`
+	Kind            string `json:"kind"`            // "workday", "weekend", "holiday" or "leave"
+	Label           string `json:"label,omitempty"` // Holiday name or leave type
+	ExpectedMinutes int    `json:"expectedMinutes"`
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func createLeave(c *fiber.Ctx) error {
+	var leave Leave
+	if err := c.BodyParser(&leave); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if leave.Type != "annual" && leave.Type != "sick" && leave.Type != "unpaid" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid leave type. Must be 'annual', 'sick' or 'unpaid'",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz izin türü. Yıllık, hastalık veya ücretsiz izin seçiniz.",
+		})
+	}
+	if leave.StartDate.IsZero() || leave.EndDate.IsZero() || leave.EndDate.Before(leave.StartDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A valid start and end date are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen geçerli izin tarihleri giriniz.",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name, ok := employeeName(ctx, leave.EmployeeID)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Employee not found",
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Personel bulunamadı",
+		})
+	}
+
+	leave.ID = primitive.NewObjectID()
+	leave.EmployeeName = name
+	leave.StartDate = startOfDay(leave.StartDate)
+	leave.EndDate = startOfDay(leave.EndDate)
+	leave.Status = "pending"
+	leave.DecisionNote = ""
+	leave.DecidedAt = nil
+	leave.DecidedBy = ""
+	leave.CreatedAt = time.Now()
+
+	if _, err := db.Collection("leaves").InsertOne(ctx, leave); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create leave: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "İzin talebi oluşturulurken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "İzin talebi onaya gönderildi.",
+		"data":  leave,
+	})
+}
+
+func getLeaves(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter["employeeId"] = id
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Geçersiz tarih formatı",
+			})
+		}
+		filter["startDate"] = bson.M{"$lte": to}
+		filter["endDate"] = bson.M{"$gte": startOfDay(from)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("leaves").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch leaves: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	leaves := []Leave{}
+	if err = cursor.All(ctx, &leaves); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode leaves: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": leaves,
+	})
+}
+
+func decideLeave(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var decision struct {
+		Approved bool   `json:"approved"`
+		Note     string `json:"note"`
+	}
+	if err := c.BodyParser(&decision); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	status := "rejected"
+	text := "İzin talebi reddedildi."
+	if decision.Approved {
+		status = "approved"
+		text = "İzin talebi onaylandı."
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("leaves").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": "pending"},
+		bson.M{"$set": bson.M{"status": status, "decisionNote": decision.Note, "decidedAt": time.Now(), "decidedBy": adminName(c)}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update leave: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Pending leave not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  text,
+	})
+}
+
+func createHoliday(c *fiber.Ctx) error {
+	var holiday Holiday
+	if err := c.BodyParser(&holiday); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if holiday.Date.IsZero() || holiday.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Holiday date and name are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen tatil tarihini ve adını giriniz.",
+		})
+	}
+
+	holiday.ID = primitive.NewObjectID()
+	holiday.Date = startOfDay(holiday.Date)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := db.Collection("holidays").CountDocuments(ctx, bson.M{"date": holiday.Date})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check holidays: " + err.Error()})
+	}
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Holiday already exists on this date",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bu tarihte zaten bir tatil tanımlı.",
+		})
+	}
+
+	if _, err := db.Collection("holidays").InsertOne(ctx, holiday); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create holiday: " + err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Tatil günü eklendi.",
+		"data":  holiday,
+	})
+}
+
+func getHolidays(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Geçersiz tarih formatı",
+			})
+		}
+		filter["date"] = bson.M{"$gte": from, "$lte": to}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("holidays").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch holidays: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	holidays := []Holiday{}
+	if err = cursor.All(ctx, &holidays); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode holidays: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": holidays,
+	})
+}
+
+func deleteHoliday(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("holidays").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete holiday: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Holiday not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Holiday deleted successfully"})
+}
+
+// availability returns, for each day between from and to, whether the
+// employee was expected to work and for how long. Holidays and approved
+// leaves have no expected hours.
+func availability(ctx context.Context, employee Employee, from, to time.Time) ([]DayAvailability, error) {
+	from, to = startOfDay(from), startOfDay(to)
+
+	cursor, err := db.Collection("holidays").Find(ctx, bson.M{"date": bson.M{"$gte": from, "$lte": to}})
+	if err != nil {
+		return nil, err
+	}
+	var holidays []Holiday
+	if err = cursor.All(ctx, &holidays); err != nil {
+		return nil, err
+	}
+
+	cursor, err = db.Collection("leaves").Find(ctx, bson.M{
+		"employeeId": employee.ID,
+		"status":     "approved",
+		"startDate":  bson.M{"$lte": to},
+		"endDate":    bson.M{"$gte": from},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var leaves []Leave
+	if err = cursor.All(ctx, &leaves); err != nil {
+		return nil, err
+	}
+
+	holidayNames := map[string]string{}
+	for _, holiday := range holidays {
+		holidayNames[startOfDay(holiday.Date).Format("2006-01-02")] = holiday.Name
+	}
+
+	weeklyHours := employee.WeeklyHours
+	if weeklyHours == 0 {
+		weeklyHours = defaultWeeklyHours
+	}
+	dailyMinutes := int(weeklyHours * 60 / 5)
+
+	var days []DayAvailability
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		entry := DayAvailability{Date: key, Kind: "workday", ExpectedMinutes: dailyMinutes}
+
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			entry.Kind = "weekend"
+		}
+		if name, ok := holidayNames[key]; ok {
+			entry.Kind = "holiday"
+			entry.Label = name
+		}
+		for _, leave := range leaves {
+			if !day.Before(startOfDay(leave.StartDate)) && !day.After(startOfDay(leave.EndDate)) {
+				entry.Kind = "leave"
+				entry.Label = leave.Type
+			}
+		}
+		// Days outside the employment period are not expected either
+		if (employee.StartDate != nil && day.Before(startOfDay(*employee.StartDate))) ||
+			(employee.EndDate != nil && day.After(startOfDay(*employee.EndDate))) {
+			entry.ExpectedMinutes = 0
+		}
+		if entry.Kind != "workday" {
+			entry.ExpectedMinutes = 0
+		}
+		days = append(days, entry)
+	}
+	return days, nil
+}
+
+func getAvailability(c *fiber.Ctx) error {
+	employeeID, err := primitive.ObjectIDFromHex(c.Query("employeeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz personel ID formatı",
+		})
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var employee Employee
+	err = db.Collection("employees").FindOne(ctx, bson.M{"_id": employeeID}).Decode(&employee)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Personel bulunamadı",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Personel bilgisi alınırken bir hata oluştu",
+		})
+	}
+
+	days, err := availability(ctx, employee, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compute availability: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": days,
+	})
+}
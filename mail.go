@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:embed email_templates/*.html
+var emailTemplateFiles embed.FS
+
+var emailTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"label":    func(key string) string { return label(workTypeLabels, key) },
+	"time":     func(t time.Time) string { return formatExportTime(t, exportDateTimeFormat) },
+}).ParseFS(emailTemplateFiles, "email_templates/*.html"))
+
+// smtpConfig is read from SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD
+// and SMTP_FROM. Mail is disabled while SMTP_HOST is empty.
+type smtpConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func loadSMTPConfig() (smtpConfig, bool) {
+	config := smtpConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	if config.From == "" {
+		config.From = config.Username
+	}
+	return config, config.Host != "" && config.From != ""
+}
+
+// adminEmails lists the digest recipients from the comma separated
+// ADMIN_EMAILS variable.
+func adminEmails() []string {
+	var emails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// sendEmail renders an HTML template and sends it. The connection is
+// upgraded with STARTTLS when the server offers it.
+func sendEmail(to []string, subject, templateName string, data interface{}) error {
+	config, ok := loadSMTPConfig()
+	if !ok {
+		return fmt.Errorf("SMTP is not configured")
+	}
+
+	var html bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&html, templateName, data); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write(html.Bytes()); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+
+	return deliverEmail(config, to, msg.Bytes())
+}
+
+// smtpTimeout bounds a whole SMTP exchange so an unresponsive server cannot
+// hold up the event subscriber that sends the mail.
+const smtpTimeout = 30 * time.Second
+
+// deliverEmail does what smtp.SendMail does, with a dial timeout and a
+// deadline on the connection.
+func deliverEmail(config smtpConfig, to []string, msg []byte) error {
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(config.Host, config.Port))
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: config.Host}); err != nil {
+			return err
+		}
+	}
+	if config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(config.From); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailReviewNotice tells the author of a video that a review was added. It
+// is subscribed to the event bus.
+func emailReviewNotice(event Event) {
+	if event.Type != EventReviewSubmitted {
+		return
+	}
+	if _, ok := loadSMTPConfig(); !ok {
+		return
+	}
+	review, ok := event.Data.(Work)
+	if !ok || review.ReviewedVideoID.IsZero() || len(review.Reviews) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var video Work
+	if err := db.Collection("works").FindOne(ctx, bson.M{"_id": review.ReviewedVideoID}).Decode(&video); err != nil {
+		log.Printf("Error loading reviewed video for email: %v", err)
+		return
+	}
+	var author Employee
+	if err := db.Collection("employees").FindOne(ctx, bson.M{"_id": video.EmployeeID}).Decode(&author); err != nil || author.Email == "" {
+		return
+	}
+	if !claimEvent(ctx, "review-email", event.ID) {
+		return
+	}
+
+	data := fiber.Map{
+		"Author": author,
+		"Video":  video,
+		"Review": review.Reviews[len(review.Reviews)-1],
+	}
+	if err := sendEmail([]string{author.Email}, "Videonuz incelendi", "review.html", data); err != nil {
+		log.Printf("Error sending review email to %s: %v", author.Email, err)
+	}
+}
+
+type DigestEmployee struct {
+	Name         string
+	Works        []Work
+	TotalMinutes int
+}
+
+type DigestData struct {
+	Date            time.Time
+	Employees       []DigestEmployee
+	AutoClosedWorks []Work
+	PendingReviews  []Work
+}
+
+// digestEmployees groups a day's completed works per employee. Works are
+// grouped by ID, the name of the first work is shown.
+func digestEmployees(works []Work) []DigestEmployee {
+	byEmployee := map[primitive.ObjectID]*DigestEmployee{}
+	var order []primitive.ObjectID
+	for _, work := range works {
+		entry, ok := byEmployee[work.EmployeeID]
+		if !ok {
+			entry = &DigestEmployee{Name: work.EmployeeName}
+			byEmployee[work.EmployeeID] = entry
+			order = append(order, work.EmployeeID)
+		}
+		entry.Works = append(entry.Works, work)
+		entry.TotalMinutes += work.DurationMinutes
+	}
+	employees := make([]DigestEmployee, 0, len(order))
+	for _, id := range order {
+		employees = append(employees, *byEmployee[id])
+	}
+	sort.SliceStable(employees, func(i, j int) bool { return employees[i].Name < employees[j].Name })
+	return employees
+}
+
+// buildDigest collects the works started on the given day, the works the
+// system closed automatically that day and the videos waiting for review.
+func buildDigest(ctx context.Context, day time.Time) (DigestData, error) {
+	from := startOfDay(day)
+	to := from.AddDate(0, 0, 1)
+	data := DigestData{Date: from}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"startTime": bson.M{"$gte": from, "$lt": to},
+		"status":    "completed",
+		"deletedAt": bson.M{"$exists": false},
+	}, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return data, err
+	}
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return data, err
+	}
+	data.Employees = digestEmployees(works)
+
+	cursor, err = db.Collection("works").Find(ctx, bson.M{
+		"autoClosedAt": bson.M{"$gte": from, "$lt": to},
+		"deletedAt":    bson.M{"$exists": false},
+	}, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return data, err
+	}
+	if err = cursor.All(ctx, &data.AutoClosedWorks); err != nil {
+		return data, err
+	}
+
+	cursor, err = db.Collection("works").Find(ctx, pendingReviewFilter(), options.Find().SetSort(bson.M{"endTime": 1}))
+	if err != nil {
+		return data, err
+	}
+	err = cursor.All(ctx, &data.PendingReviews)
+	return data, err
+}
+
+func sendDailyDigest(day time.Time) error {
+	recipients := adminEmails()
+	if len(recipients) == 0 {
+		return fmt.Errorf("ADMIN_EMAILS is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := buildDigest(ctx, day)
+	if err != nil {
+		return err
+	}
+	subject := "Günlük özet - " + data.Date.Format(exportDateFormat)
+	return sendEmail(recipients, subject, "digest.html", data)
+}
+
+// runDailyDigest mails the previous day's digest to the admins every day at
+// DIGEST_HOUR (local time, default 8). It does nothing unless SMTP and
+// ADMIN_EMAILS are configured.
+func runDailyDigest() {
+	if _, ok := loadSMTPConfig(); !ok || len(adminEmails()) == 0 {
+		return
+	}
+	hour, err := strconv.Atoi(os.Getenv("DIGEST_HOUR"))
+	if err != nil || hour < 0 || hour > 23 {
+		hour = 8
+	}
+
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.Local)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(time.Until(next))
+
+		if err := sendDailyDigest(next.AddDate(0, 0, -1)); err != nil {
+			log.Printf("Error sending daily digest: %v", err)
+		}
+	}
+}
+
+// getDigestPreview renders the digest of a day (default yesterday) as HTML
+// without sending it.
+func getDigestPreview(c *fiber.Ctx) error {
+	day := time.Now().AddDate(0, 0, -1)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		}
+		day = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := buildDigest(ctx, day)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build digest: " + err.Error()})
+	}
+
+	var html bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&html, "digest.html", data); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to render digest: " + err.Error()})
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(html.Bytes())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDigestEmployees(t *testing.T) {
+	ayse, ayseNamesake, ali := primitive.ObjectID{1}, primitive.ObjectID{2}, primitive.ObjectID{3}
+	works := []Work{
+		{EmployeeID: ayse, EmployeeName: "Ayşe", DurationMinutes: 30},
+		{EmployeeID: ali, EmployeeName: "Ali", DurationMinutes: 45},
+		{EmployeeID: ayseNamesake, EmployeeName: "Ayşe", DurationMinutes: 10},
+		{EmployeeID: ayse, EmployeeName: "Ayşe", DurationMinutes: 20},
+	}
+
+	employees := digestEmployees(works)
+	want := []struct {
+		name    string
+		works   int
+		minutes int
+	}{
+		{"Ali", 1, 45},
+		{"Ayşe", 2, 50},
+		{"Ayşe", 1, 10},
+	}
+	if len(employees) != len(want) {
+		t.Fatalf("got %d employees, want %d", len(employees), len(want))
+	}
+	for i, w := range want {
+		got := employees[i]
+		if got.Name != w.name || len(got.Works) != w.works || got.TotalMinutes != w.minutes {
+			t.Errorf("employee %d = %s with %d works and %d minutes, want %s with %d works and %d minutes",
+				i, got.Name, len(got.Works), got.TotalMinutes, w.name, w.works, w.minutes)
+		}
+	}
+}
+
+func TestDigestTemplate(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.Local)
+	data := DigestData{
+		Date:            start,
+		AutoClosedWorks: []Work{{EmployeeName: "Ali", WorkType: "video", Description: "Unutulan kurgu", StartTime: start, EndTime: start.Add(12 * time.Hour)}},
+	}
+
+	var html bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&html, "digest.html", data); err != nil {
+		t.Fatalf("rendering digest: %v", err)
+	}
+	for _, want := range []string{"Otomatik kapatılan işler", "Unutulan kurgu", "Bu gün tamamlanan iş bulunmuyor.", "İnceleme bekleyen video bulunmuyor."} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("digest does not contain %q", want)
+		}
+	}
+}
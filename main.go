@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/template/html/v2"
@@ -22,10 +26,17 @@ var (
 )
 
 type Employee struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name      string            `json:"name" bson:"name"`
-	Type      string            `json:"type" bson:"type"` // "staff" or "intern"
-	DeletedAt *time.Time        `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name         string             `json:"name" bson:"name"`
+	Type         string             `json:"type" bson:"type"` // "staff" or "intern"
+	Email        string             `json:"email,omitempty" bson:"email,omitempty"`
+	StartDate    *time.Time         `json:"startDate,omitempty" bson:"startDate,omitempty"`
+	EndDate      *time.Time         `json:"endDate,omitempty" bson:"endDate,omitempty"`
+	WeeklyHours  float64            `json:"weeklyHours,omitempty" bson:"weeklyHours,omitempty"` // Contracted hours per week
+	TeamID       primitive.ObjectID `json:"teamId,omitempty" bson:"teamId,omitempty"`
+	ManagerID    primitive.ObjectID `json:"managerId,omitempty" bson:"managerId,omitempty"` // Supervising staff member
+	DeletedAt    *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	AnonymizedAt *time.Time         `json:"anonymizedAt,omitempty" bson:"anonymizedAt,omitempty"` // Name scrubbed on a data-removal request
 }
 
 type WorkStats struct {
@@ -53,12 +64,24 @@ type Work struct {
 	RevisedBy       primitive.ObjectID `json:"revisedBy,omitempty" bson:"revisedBy,omitempty"`             // Employee who did the revision
 	RevisedByName   string             `json:"revisedByName,omitempty" bson:"revisedByName,omitempty"`     // Name of employee who did the revision
 	ReviewedVideoID primitive.ObjectID `json:"reviewedVideoId,omitempty" bson:"reviewedVideoId,omitempty"` // ID of the video being reviewed
+	RevisionStatus  string             `json:"revisionStatus,omitempty" bson:"revisionStatus,omitempty"`   // "approved" once the video is accepted
+	RevisionNote    string             `json:"revisionNote,omitempty" bson:"revisionNote,omitempty"`       // Reviewer note for the revision
+	TaskID          primitive.ObjectID `json:"taskId,omitempty" bson:"taskId,omitempty"`                   // Planned task this work was started from
+	IsManual        bool               `json:"isManual,omitempty" bson:"isManual,omitempty"`               // Entered retroactively instead of with the timer
+	ManualEntry     *ManualEntry       `json:"manualEntry,omitempty" bson:"manualEntry,omitempty"`         // Approval trail for manual entries
 	Reviews         []Review           `json:"reviews" bson:"reviews"`                                     // Reviews for this video
 	StartTime       time.Time          `json:"startTime" bson:"startTime"`
 	EndTime         time.Time          `json:"endTime,omitempty" bson:"endTime,omitempty"`
 	Duration        string             `json:"duration,omitempty" bson:"duration,omitempty"`
 	DurationMinutes int                `json:"durationMinutes,omitempty" bson:"durationMinutes,omitempty"`
-	Status          string             `json:"status" bson:"status"` // "in_progress" or "completed"
+	PausedAt        *time.Time         `json:"pausedAt,omitempty" bson:"pausedAt,omitempty"`           // Set while the work is paused
+	Pauses          []PauseInterval    `json:"pauses,omitempty" bson:"pauses,omitempty"`               // Finished pauses, the open one is PausedAt
+	PausedMinutes   int                `json:"pausedMinutes,omitempty" bson:"pausedMinutes,omitempty"` // Paused time excluded from the duration
+	HasOverlap      bool               `json:"hasOverlap,omitempty" bson:"hasOverlap,omitempty"`       // Started while another work was in progress
+	AutoClosedAt    *time.Time         `json:"autoClosedAt,omitempty" bson:"autoClosedAt,omitempty"`   // Closed by the system after WORK_AUTO_CLOSE_HOURS
+	DeletedAt       *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeleteReason    string             `json:"deleteReason,omitempty" bson:"deleteReason,omitempty"`
+	Status          string             `json:"status" bson:"status"` // "in_progress", "paused", "completed", "pending_approval" or "rejected"
 }
 
 type Review struct {
@@ -106,6 +129,26 @@ func main() {
 		}
 	}()
 
+	// "backup", "restore" and "backfill-history" run instead of the server
+	if handled, err := runCommand(os.Args[1:]); handled {
+		if err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Event subscribers
+	subscribe(dispatchWebhooks)
+	subscribe(notifyChatChannels)
+	subscribe(emailReviewNotice)
+	subscribe(storeInboxNotifications)
+	subscribe(broadcastEvent)
+
+	watchChangeStreams()
+	runWebhookDeliveries()
+	go runAutoCloseWorks()
+	go runDailyDigest()
+
 	// Initialize template engine
 	engine := html.New("./templates", ".html")
 
@@ -131,10 +174,66 @@ func main() {
 	})
 
 	// API Routes
-	api := app.Group("/api")
+	api := app.Group("/api", auditLog)
 	api.Post("/employees", createEmployee)
 	api.Get("/employees", getEmployees)
+	api.Put("/employees/:id", updateEmployee)
 	api.Delete("/employees/:id", deleteEmployee)
+	api.Post("/teams", createTeam)
+	api.Get("/teams", getTeams)
+	api.Put("/teams/:id", updateTeam)
+	api.Delete("/teams/:id", deleteTeam)
+	api.Get("/team-stats", getTeamStats)
+	api.Get("/team-timeline", getTeamTimeline)
+	api.Post("/internships", createInternship)
+	api.Get("/internships", getInternships)
+	api.Put("/internships/:id", updateInternship)
+	api.Post("/internships/:id/evaluations", createEvaluation)
+	api.Get("/internships/:id/evaluations", getEvaluations)
+	api.Get("/internships/:id/report", getInternshipReport)
+	api.Post("/leaves", createLeave)
+	api.Get("/leaves", getLeaves)
+	api.Put("/leaves/:id/approval", requireAdmin, decideLeave)
+	api.Post("/holidays", requireAdmin, createHoliday)
+	api.Get("/holidays", getHolidays)
+	api.Delete("/holidays/:id", requireAdmin, deleteHoliday)
+	api.Get("/availability", getAvailability)
+	api.Get("/utilization", getUtilization)
+	api.Post("/attendance/check-in", checkIn)
+	api.Post("/attendance/check-out", checkOut)
+	api.Post("/attendance/break-start", startBreak)
+	api.Post("/attendance/break-end", endBreak)
+	api.Get("/attendance", getAttendance)
+	api.Get("/attendance/comparison", getAttendanceComparison)
+	api.Get("/export/works", exportWorks)
+	api.Get("/export/timesheet", exportTimesheets)
+	api.Get("/employees/:id/timesheet.pdf", getTimesheetPDF)
+	api.Post("/employees/:id/calendar-feed", requireAdmin, createCalendarFeed)
+	api.Delete("/employees/:id/calendar-feed", requireAdmin, deleteCalendarFeed)
+	api.Post("/teams/:id/calendar-feed", requireAdmin, createCalendarFeed)
+	api.Delete("/teams/:id/calendar-feed", requireAdmin, deleteCalendarFeed)
+	api.Get("/calendar/:token", getCalendarFeed)
+	api.Post("/import/employees", requireAdmin, importEmployees)
+	api.Post("/import/works", requireAdmin, importWorks)
+	api.Post("/webhooks", requireAdmin, createWebhook)
+	api.Get("/webhooks", requireAdmin, getWebhooks)
+	api.Put("/webhooks/:id", requireAdmin, updateWebhook)
+	api.Delete("/webhooks/:id", requireAdmin, deleteWebhook)
+	api.Get("/webhooks/:id/deliveries", requireAdmin, getWebhookDeliveries)
+	api.Post("/chat-channels", requireAdmin, createChatChannel)
+	api.Get("/chat-channels", requireAdmin, getChatChannels)
+	api.Put("/chat-channels/:id", requireAdmin, updateChatChannel)
+	api.Delete("/chat-channels/:id", requireAdmin, deleteChatChannel)
+	api.Post("/chat-channels/:id/test", requireAdmin, testChatChannel)
+	api.Get("/digest/preview", requireAdmin, getDigestPreview)
+	api.Get("/notifications", getNotifications)
+	api.Get("/notifications/unread-count", getUnreadNotificationCount)
+	api.Put("/notifications/read-all", markAllNotificationsRead)
+	api.Put("/notifications/:id/read", markNotificationRead)
+	api.Post("/stream/ticket", createStreamTicket)
+	api.Get("/stream", streamEvents)
+	api.Post("/employees/:id/restore", restoreEmployee)
+	api.Post("/employees/:id/anonymize", requireAdmin, anonymizeEmployee)
 	api.Post("/work", createWork)
 	api.Put("/work/:id", updateWork)
 	api.Get("/works", getAllWorks)
@@ -144,6 +243,26 @@ func main() {
 	api.Get("/approved-videos", getApprovedVideos)
 	api.Get("/completed-videos", getCompletedVideos)
 	api.Get("/reviewed-videos", getReviewedVideos)
+	api.Post("/tasks", requireAdmin, createTask)
+	api.Get("/tasks", getTasks)
+	api.Put("/tasks/:id", requireAdmin, updateTask)
+	api.Delete("/tasks/:id", requireAdmin, deleteTask)
+	api.Post("/tasks/:id/start", startTask)
+	api.Get("/plan-comparison", getPlanComparison)
+	api.Post("/work/manual", createManualWork)
+	api.Get("/manual-works", getManualWorks)
+	api.Put("/work/:id/approval", requireAdmin, decideManualWork)
+	api.Put("/work/:id/pause", pauseWork)
+	api.Put("/work/:id/resume", resumeWork)
+	api.Get("/work-overlaps", getWorkOverlaps)
+	api.Put("/work/:id/correction", requireAdmin, correctWork)
+	api.Get("/work/:id/changes", getWorkChanges)
+	api.Get("/work/:id/history", getWorkHistory)
+	api.Get("/work/:id/history/rebuild", getRebuiltWork)
+	api.Post("/work-history/rebuild", requireAdmin, rebuildWorks)
+	api.Delete("/work/:id", deleteWork)
+	api.Get("/audit-log", requireAdmin, getAuditLog)
+	api.Post("/work/:id/restore", restoreWork)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -171,10 +290,21 @@ func createEmployee(c *fiber.Ctx) error {
 	}
 
 	employee.ID = primitive.NewObjectID()
+	employee.DeletedAt = nil
+	employee.AnonymizedAt = nil
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if err := validateEmployeeProfile(ctx, employee); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Personel bilgileri geçersiz: " + err.Error(),
+		})
+	}
+
 	result, err := db.Collection("employees").InsertOne(ctx, employee)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -203,6 +333,15 @@ func getEmployees(c *fiber.Ctx) error {
 	if !includeDeleted {
 		filter["deletedAt"] = bson.M{"$exists": false}
 	}
+	for _, field := range []string{"teamId", "managerId"} {
+		if value := c.Query(field); value != "" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + field + " format"})
+			}
+			filter[field] = id
+		}
+	}
 
 	cursor, err := db.Collection("employees").Find(ctx, filter)
 	if err != nil {
@@ -278,6 +417,7 @@ func getEmployeeStats(c *fiber.Ctx) error {
 	completedWorks, err := db.Collection("works").Find(ctx, bson.M{
 		"employeeId": employeeId,
 		"status":     "completed",
+		"deletedAt":  bson.M{"$exists": false},
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works"})
@@ -289,6 +429,11 @@ func getEmployeeStats(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works"})
 	}
 
+	return c.JSON(workStats(works))
+}
+
+// workStats computes the average durations of completed works.
+func workStats(works []Work) WorkStats {
 	var videoWorks []Work
 	var softwareWorks []Work
 	for _, work := range works {
@@ -321,7 +466,7 @@ func getEmployeeStats(c *fiber.Ctx) error {
 		stats.AverageSoftwareDuration = formatDuration(avgMinutes)
 	}
 
-	return c.JSON(stats)
+	return stats
 }
 
 func getDailyTimeline(c *fiber.Ctx) error {
@@ -394,6 +539,9 @@ func getDailyTimeline(c *fiber.Ctx) error {
 		},
 		"employeeId": employeeObjID,
 	}
+	if c.Query("includeDeleted") != "true" {
+		filter["deletedAt"] = bson.M{"$exists": false}
+	}
 
 	works, err := db.Collection("works").Find(ctx, filter)
 	if err != nil {
@@ -414,6 +562,41 @@ func getDailyTimeline(c *fiber.Ctx) error {
 		})
 	}
 
+	// Holidays and leaves are marked so an empty day is not mistaken for absence of work
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	days, err := availability(ctx, employee, day, day)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "İzin bilgileri yüklenirken bir hata oluştu",
+		})
+	}
+
+	// Checked-in time without a work or break is shown between the works
+	gaps := []TimeInterval{}
+	comparison, err := attendanceComparison(ctx, employeeObjID, day, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Giriş/çıkış kayıtları yüklenirken bir hata oluştu",
+		})
+	}
+	if comparison != nil {
+		gaps = comparison.Gaps
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": buildTimeline(employeeObjID, dailyWorks),
+		"day":  days[0],
+		"gaps": gaps,
+	})
+}
+
+// buildTimeline places an employee's works into hourly slots between 09:00 and 18:00.
+func buildTimeline(employeeID primitive.ObjectID, works []Work) []TimelineSlot {
 	timeline := make([]TimelineSlot, 10)
 	for i := 0; i < 10; i++ {
 		timeline[i] = TimelineSlot{
@@ -422,8 +605,8 @@ func getDailyTimeline(c *fiber.Ctx) error {
 		}
 	}
 
-	for _, work := range dailyWorks {
-		if work.EmployeeID == employeeObjID {
+	for _, work := range works {
+		if work.EmployeeID == employeeID {
 			hour := work.StartTime.Hour()
 			if hour >= 9 && hour < 18 {
 				slotIndex := hour - 9
@@ -432,10 +615,24 @@ func getDailyTimeline(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.JSON(fiber.Map{
-		"type": "success",
-		"data": timeline,
-	})
+	return timeline
+}
+
+// validateVideoLink accepts an empty link or an http(s) URL. Control
+// characters are refused so a link cannot break out of its line in the
+// exported calendars.
+func validateVideoLink(link string) error {
+	if link == "" {
+		return nil
+	}
+	if strings.IndexFunc(link, unicode.IsControl) >= 0 {
+		return errors.New("video link contains control characters")
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("video link must be an http or https URL")
+	}
+	return nil
 }
 
 func createWork(c *fiber.Ctx) error {
@@ -444,16 +641,97 @@ func createWork(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := validateVideoLink(work.VideoLink); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz video bağlantısı. Lütfen http veya https ile başlayan bir adres giriniz.",
+		})
+	}
+
 	work.ID = primitive.NewObjectID()
 	work.Status = "in_progress"
+	work.RevisionStatus = ""
+	work.IsManual = false
+	work.ManualEntry = nil
+	work.PausedAt = nil
+	work.Pauses = nil
+	work.PausedMinutes = 0
+	work.HasOverlap = false
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if name, ok := employeeName(ctx, work.EmployeeID); ok {
+		work.EmployeeName = name
+	}
+
+	// A work can only be linked to an unfinished task assigned to the same employee
+	if !work.TaskID.IsZero() {
+		var task Task
+		err := db.Collection("tasks").FindOne(ctx, bson.M{"_id": work.TaskID}).Decode(&task)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Task not found",
+					"type":  "warning",
+					"title": "Uyarı",
+					"text":  "Seçilen görev bulunamadı.",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch task: " + err.Error()})
+		}
+		if task.EmployeeID != work.EmployeeID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Task is assigned to another employee",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Bu görev başka bir personele atanmış.",
+			})
+		}
+		if task.Status == "completed" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Task already completed",
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Bu görev zaten tamamlanmış.",
+			})
+		}
+	}
+
+	toPause, err := applyOverlapPolicy(ctx, &work)
+	if err != nil {
+		if err == errWorkOverlap {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Devam eden başka bir işiniz var. Lütfen önce onu tamamlayınız.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check running works: " + err.Error()})
+	}
+
 	result, err := db.Collection("works").InsertOne(ctx, work)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create work: " + err.Error()})
 	}
+	if err := pauseRunningWorks(ctx, toPause); err != nil {
+		log.Printf("Error pausing running works: %v", err)
+	}
+
+	// Work started from a planned task moves the task out of the backlog
+	if !work.TaskID.IsZero() {
+		_, err = db.Collection("tasks").UpdateOne(
+			ctx,
+			bson.M{"_id": work.TaskID, "status": "open"},
+			bson.M{"$set": bson.M{"status": "in_progress"}},
+		)
+		if err != nil {
+			log.Printf("Error updating task status: %v", err)
+		}
+	}
 
 	work.ID = result.InsertedID.(primitive.ObjectID)
 	return c.Status(fiber.StatusCreated).JSON(work)
@@ -481,11 +759,20 @@ func updateWork(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := validateVideoLink(update.VideoLink); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz video bağlantısı. Lütfen http veya https ile başlayan bir adres giriniz.",
+		})
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	var work Work
-	err = db.Collection("works").FindOne(ctx, bson.M{"_id": id}).Decode(&work)
+	err = db.Collection("works").FindOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}).Decode(&work)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Work not found"})
 	}
@@ -502,10 +789,24 @@ func updateWork(c *fiber.Ctx) error {
 		}
 	}
 
+	// Manual entries only change status through the approval endpoint
+	if work.Status == "pending_approval" && update.Status != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Manual work is waiting for approval",
+		})
+	}
+
 	updateFields := bson.M{}
+	updateQuery := bson.M{}
 	if !update.EndTime.IsZero() {
-		duration := update.EndTime.Sub(work.StartTime)
+		pausedMinutes := pausedMinutesUntil(work, update.EndTime)
+		duration := update.EndTime.Sub(work.StartTime) - time.Duration(pausedMinutes)*time.Minute
 		durationMinutes := int(duration.Minutes())
+		if work.PausedAt != nil {
+			updateFields["pausedMinutes"] = pausedMinutes
+			updateQuery["$unset"] = bson.M{"pausedAt": ""}
+			updateQuery["$push"] = bson.M{"pauses": PauseInterval{Start: *work.PausedAt, End: update.EndTime}}
+		}
 		updateFields["endTime"] = update.EndTime
 		updateFields["duration"] = duration.String()
 		updateFields["durationMinutes"] = durationMinutes
@@ -524,8 +825,23 @@ func updateWork(c *fiber.Ctx) error {
 	}
 	if update.Status != "" {
 		updateFields["status"] = update.Status
+		if update.Status == "completed" && !work.TaskID.IsZero() {
+			_, err = db.Collection("tasks").UpdateOne(
+				ctx,
+				bson.M{"_id": work.TaskID},
+				bson.M{"$set": bson.M{"status": "completed"}},
+			)
+			if err != nil {
+				log.Printf("Error updating task status: %v", err)
+			}
+		}
 	}
 	if len(update.Reviews) > 0 {
+		for i, review := range update.Reviews {
+			if name, ok := employeeName(ctx, review.ReviewerID); ok {
+				update.Reviews[i].ReviewerName = name
+			}
+		}
 		updateFields["reviews"] = update.Reviews
 		// Video incelemesi tamamlandığında, incelenen videoyu güncelle
 		if work.ReviewedVideoID != primitive.NilObjectID {
@@ -543,12 +859,16 @@ func updateWork(c *fiber.Ctx) error {
 		updateFields["isBeingReviewed"] = true
 		updateFields["revisedBy"] = update.RevisedBy
 		updateFields["revisedByName"] = update.RevisedByName
+		if name, ok := employeeName(ctx, update.RevisedBy); ok {
+			updateFields["revisedByName"] = name
+		}
 	}
 
+	updateQuery["$set"] = updateFields
 	result, err := db.Collection("works").UpdateOne(
 		ctx,
 		bson.M{"_id": id},
-		bson.M{"$set": updateFields},
+		updateQuery,
 	)
 
 	if err != nil {
@@ -563,10 +883,15 @@ func updateWork(c *fiber.Ctx) error {
 }
 
 func getAllWorks(c *fiber.Ctx) error {
+	filter, err := worksFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	cursor, err := db.Collection("works").Find(ctx, bson.M{})
+	cursor, err := db.Collection("works").Find(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
 	}
@@ -580,6 +905,36 @@ func getAllWorks(c *fiber.Ctx) error {
 	return c.JSON(works)
 }
 
+// worksFilter builds the works query from the listing's query parameters:
+// employeeId, workType, status, from/to (start day) and includeDeleted.
+func worksFilter(c *fiber.Ctx) (bson.M, error) {
+	filter := bson.M{}
+	if c.Query("includeDeleted") != "true" {
+		filter["deletedAt"] = bson.M{"$exists": false}
+	}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid employee ID format")
+		}
+		filter["employeeId"] = id
+	}
+	if workType := c.Query("workType"); workType != "" {
+		filter["workType"] = workType
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format")
+		}
+		filter["startTime"] = bson.M{"$gte": from, "$lte": to}
+	}
+	return filter, nil
+}
+
 func getWork(c *fiber.Ctx) error {
 	id, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
@@ -601,6 +956,73 @@ func getWork(c *fiber.Ctx) error {
 	return c.JSON(work)
 }
 
+func deleteWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if body.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A reason for deleting the work is required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen silme sebebini giriniz.",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("works").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": time.Now(), "deleteReason": body.Reason}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete work: " + err.Error()})
+	}
+
+	if result.ModifiedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Work not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Work deleted successfully"})
+}
+
+func restoreWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("works").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"deletedAt": "", "deleteReason": ""}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to restore work: " + err.Error()})
+	}
+
+	if result.ModifiedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Deleted work not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Work restored successfully"})
+}
+
 func getApprovedVideos(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -609,6 +1031,7 @@ func getApprovedVideos(c *fiber.Ctx) error {
 		"workType":       "video",
 		"status":         "completed",
 		"revisionStatus": "approved",
+		"deletedAt":      bson.M{"$exists": false},
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -645,6 +1068,25 @@ func getApprovedVideos(c *fiber.Ctx) error {
 	})
 }
 
+// pendingReviewFilter matches the completed videos and revisions that have
+// not been reviewed yet.
+func pendingReviewFilter() bson.M {
+	return bson.M{
+		"status":    "completed",
+		"deletedAt": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{
+				"workType":   "video",
+				"isReviewed": bson.M{"$ne": true},
+			},
+			{
+				"workType":   "revize",
+				"isReviewed": bson.M{"$ne": true},
+			},
+		},
+	}
+}
+
 func getCompletedVideos(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -670,19 +1112,7 @@ func getCompletedVideos(c *fiber.Ctx) error {
 		endTime = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, time.Local)
 	}
 
-	filter := bson.M{
-		"status": "completed",
-		"$or": []bson.M{
-			{
-				"workType":   "video",
-				"isReviewed": bson.M{"$ne": true},
-			},
-			{
-				"workType":   "revize",
-				"isReviewed": bson.M{"$ne": true},
-			},
-		},
-	}
+	filter := pendingReviewFilter()
 
 	// Add date filter if provided
 	if dateStr != "" {
@@ -733,9 +1163,10 @@ func getReviewedVideos(c *fiber.Ctx) error {
 	defer cancel()
 
 	cursor, err := db.Collection("works").Find(ctx, bson.M{
-		"workType": "video",
-		"status":   "completed",
-		"reviews":  bson.M{"$exists": true, "$ne": []interface{}{}},
+		"workType":  "video",
+		"status":    "completed",
+		"reviews":   bson.M{"$exists": true, "$ne": []interface{}{}},
+		"deletedAt": bson.M{"$exists": false},
 	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
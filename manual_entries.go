@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ManualEntry records how a retroactively submitted work was approved.
+type ManualEntry struct {
+	Status       string     `json:"status" bson:"status"` // "pending", "approved" or "rejected"
+	Reason       string     `json:"reason" bson:"reason"` // Why the timer was not used
+	SubmittedAt  time.Time  `json:"submittedAt" bson:"submittedAt"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty" bson:"decidedAt,omitempty"`
+	DecidedBy    string     `json:"decidedBy,omitempty" bson:"decidedBy,omitempty"` // Admin who approved or rejected the entry
+	DecisionNote string     `json:"decisionNote,omitempty" bson:"decisionNote,omitempty"`
+}
+
+// createManualWork stores a work with explicit start and end times. It stays
+// in "pending_approval" status, and out of the stats, until an admin approves it.
+func createManualWork(c *fiber.Ctx) error {
+	var body struct {
+		EmployeeID   primitive.ObjectID `json:"employeeId"`
+		WorkType     string             `json:"workType"`
+		Description  string             `json:"description"`
+		VideoLink    string             `json:"videoLink"`
+		IsFirstVideo bool               `json:"isFirstVideo"`
+		StartTime    time.Time          `json:"startTime"`
+		EndTime      time.Time          `json:"endTime"`
+		Reason       string             `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	work := Work{
+		EmployeeID:   body.EmployeeID,
+		WorkType:     body.WorkType,
+		Description:  body.Description,
+		VideoLink:    body.VideoLink,
+		IsFirstVideo: body.IsFirstVideo,
+		StartTime:    body.StartTime,
+		EndTime:      body.EndTime,
+		Reviews:      []Review{},
+	}
+
+	if work.EmployeeID.IsZero() || work.WorkType == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Employee and work type are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen personel ve iş türünü seçiniz.",
+		})
+	}
+	if err := validateVideoLink(work.VideoLink); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz video bağlantısı. Lütfen http veya https ile başlayan bir adres giriniz.",
+		})
+	}
+	if work.StartTime.IsZero() || work.EndTime.IsZero() || !work.EndTime.After(work.StartTime) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A valid start and end time are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bitiş zamanı başlangıç zamanından sonra olmalıdır.",
+		})
+	}
+	if work.EndTime.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Manual entries cannot end in the future",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geleceğe ait manuel kayıt girilemez.",
+		})
+	}
+
+	duration := work.EndTime.Sub(work.StartTime)
+	work.ID = primitive.NewObjectID()
+	work.Duration = duration.String()
+	work.DurationMinutes = int(duration.Minutes())
+	work.Status = "pending_approval"
+	work.IsManual = true
+	work.ManualEntry = &ManualEntry{
+		Status:      "pending",
+		Reason:      body.Reason,
+		SubmittedAt: time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if name, ok := employeeName(ctx, work.EmployeeID); ok {
+		work.EmployeeName = name
+	}
+
+	if _, err := db.Collection("works").InsertOne(ctx, work); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create work: " + err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Manuel kayıt onaya gönderildi.",
+		"data":  work,
+	})
+}
+
+func getManualWorks(c *fiber.Ctx) error {
+	filter := bson.M{"isManual": true, "deletedAt": bson.M{"$exists": false}}
+	if status := c.Query("status"); status != "" {
+		filter["manualEntry.status"] = status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("works").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	works := []Work{}
+	if err = cursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": works,
+	})
+}
+
+// decideManualWork approves or rejects a pending manual entry. Approved
+// entries become regular completed works.
+func decideManualWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var decision struct {
+		Approved bool   `json:"approved"`
+		Note     string `json:"note"`
+	}
+	if err := c.BodyParser(&decision); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var work Work
+	err = db.Collection("works").FindOne(ctx, bson.M{"_id": id, "isManual": true, "deletedAt": bson.M{"$exists": false}}).Decode(&work)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Manual work not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work: " + err.Error()})
+	}
+	alreadyDecided := fiber.Map{
+		"error": "Manual work already decided",
+		"type":  "warning",
+		"title": "Uyarı",
+		"text":  "Bu kayıt daha önce değerlendirilmiş.",
+	}
+	if work.ManualEntry == nil || work.ManualEntry.Status != "pending" {
+		return c.Status(fiber.StatusConflict).JSON(alreadyDecided)
+	}
+
+	now := time.Now()
+	updateFields := bson.M{
+		"manualEntry.decidedAt":    now,
+		"manualEntry.decisionNote": decision.Note,
+		"manualEntry.decidedBy":    adminName(c),
+	}
+	if decision.Approved {
+		updateFields["manualEntry.status"] = "approved"
+		updateFields["status"] = "completed"
+	} else {
+		updateFields["manualEntry.status"] = "rejected"
+		updateFields["status"] = "rejected"
+	}
+
+	// The status filter keeps a concurrent decision from being overwritten
+	result, err := db.Collection("works").UpdateOne(ctx, bson.M{"_id": id, "manualEntry.status": "pending"}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update work: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusConflict).JSON(alreadyDecided)
+	}
+
+	text := "Manuel kayıt reddedildi."
+	if decision.Approved {
+		text = "Manuel kayıt onaylandı."
+	}
+	return c.JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  text,
+	})
+}
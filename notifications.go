@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Notification struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EmployeeID primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	Kind       string             `json:"kind" bson:"kind"` // "video_reviewed", "video_approved" or "review_assigned"
+	Title      string             `json:"title" bson:"title"`
+	Text       string             `json:"text" bson:"text"`
+	WorkID     primitive.ObjectID `json:"workId,omitempty" bson:"workId,omitempty"`
+	TaskID     primitive.ObjectID `json:"taskId,omitempty" bson:"taskId,omitempty"`
+	EventID    primitive.ObjectID `json:"-" bson:"eventId,omitempty"` // Event the notification was created for
+	Read       bool               `json:"read" bson:"read"`
+	ReadAt     *time.Time         `json:"readAt,omitempty" bson:"readAt,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// inboxNotificationsFor maps a domain event to the inbox notifications it
+// produces.
+func inboxNotificationsFor(ctx context.Context, event Event) []Notification {
+	switch data := event.Data.(type) {
+	case Work:
+		switch event.Type {
+		case EventReviewSubmitted:
+			if data.ReviewedVideoID.IsZero() || len(data.Reviews) == 0 {
+				return nil
+			}
+			var video Work
+			if err := db.Collection("works").FindOne(ctx, bson.M{"_id": data.ReviewedVideoID}).Decode(&video); err != nil {
+				log.Printf("Error loading reviewed video for notification: %v", err)
+				return nil
+			}
+			review := data.Reviews[len(data.Reviews)-1]
+			text := fmt.Sprintf("%s videonuzu inceledi: %s", review.ReviewerName, video.Description)
+			if review.Comment != "" {
+				text += " - " + review.Comment
+			}
+			return []Notification{{
+				EmployeeID: video.EmployeeID,
+				Kind:       "video_reviewed",
+				Title:      "Videonuz incelendi",
+				Text:       text,
+				WorkID:     video.ID,
+			}}
+		case EventVideoApproved:
+			return []Notification{{
+				EmployeeID: data.EmployeeID,
+				Kind:       "video_approved",
+				Title:      "Videonuz onaylandı",
+				Text:       data.Description,
+				WorkID:     data.ID,
+			}}
+		}
+	case Task:
+		if event.Type == EventTaskAssigned && data.WorkType == "review" {
+			return []Notification{{
+				EmployeeID: data.EmployeeID,
+				Kind:       "review_assigned",
+				Title:      "Size bir inceleme atandı",
+				Text:       data.Title,
+				TaskID:     data.ID,
+			}}
+		}
+	}
+	return nil
+}
+
+// storeInboxNotifications is subscribed to the event bus and writes the
+// notifications shown in the employee page.
+func storeInboxNotifications(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notifications := inboxNotificationsFor(ctx, event)
+	if len(notifications) == 0 {
+		return
+	}
+	docs := make([]interface{}, 0, len(notifications))
+	for _, notification := range notifications {
+		if notification.EmployeeID.IsZero() {
+			continue
+		}
+		notification.ID = primitive.NewObjectID()
+		notification.EventID = event.ID
+		notification.CreatedAt = event.CreatedAt
+		docs = append(docs, notification)
+	}
+	if len(docs) == 0 {
+		return
+	}
+	// Notifications of a replayed event already exist and are skipped
+	_, err := db.Collection("notifications").InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		log.Printf("Error storing notifications: %v", err)
+	}
+}
+
+func notificationEmployee(c *fiber.Ctx) (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(c.Query("employeeId"))
+}
+
+// getNotifications lists an employee's latest notifications, newest first.
+// unread=true limits the list to unread ones.
+func getNotifications(c *fiber.Ctx) error {
+	employeeID, err := notificationEmployee(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+	}
+	filter := bson.M{"employeeId": employeeID}
+	if c.QueryBool("unread") {
+		filter["read"] = false
+	}
+	limit := int64(c.QueryInt("limit", 50))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("notifications").Find(ctx, filter,
+		options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch notifications: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode notifications: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": notifications,
+	})
+}
+
+func getUnreadNotificationCount(c *fiber.Ctx) error {
+	employeeID, err := notificationEmployee(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := db.Collection("notifications").CountDocuments(ctx, bson.M{"employeeId": employeeID, "read": false})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count notifications: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": fiber.Map{"unread": count},
+	})
+}
+
+func markNotificationRead(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("notifications").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notification: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Notification marked as read"})
+}
+
+func markAllNotificationsRead(c *fiber.Ctx) error {
+	employeeID, err := notificationEmployee(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("notifications").UpdateMany(ctx,
+		bson.M{"employeeId": employeeID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notifications: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notifications marked as read",
+		"updated": result.ModifiedCount,
+	})
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Policies for an employee starting a work while another one is in progress,
+// selected with the WORK_OVERLAP_POLICY environment variable.
+const (
+	overlapDisallow = "disallow" // Reject the new work
+	overlapPause    = "pause"    // Pause the running works
+	overlapFlag     = "flag"     // Allow, but mark the new work as overlapping
+)
+
+var errWorkOverlap = errors.New("employee already has a work in progress")
+
+type WorkOverlap struct {
+	EmployeeID   primitive.ObjectID   `json:"employeeId"`
+	EmployeeName string               `json:"employeeName"`
+	WorkIDs      []primitive.ObjectID `json:"workIds"`
+	Start        time.Time            `json:"start"`
+	End          time.Time            `json:"end"`
+	Minutes      int                  `json:"minutes"`
+}
+
+func overlapPolicy() string {
+	switch policy := os.Getenv("WORK_OVERLAP_POLICY"); policy {
+	case overlapDisallow, overlapPause:
+		return policy
+	default:
+		return overlapFlag
+	}
+}
+
+// PauseInterval is one finished pause of a work.
+type PauseInterval struct {
+	Start time.Time `json:"start" bson:"start"`
+	End   time.Time `json:"end" bson:"end"`
+}
+
+// applyOverlapPolicy checks the employee's running works before work starts.
+// It returns errWorkOverlap when the policy forbids starting it, and under the
+// pause policy the works to pause once the new work is stored.
+func applyOverlapPolicy(ctx context.Context, work *Work) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"employeeId": work.EmployeeID,
+		"status":     "in_progress",
+		"_id":        bson.M{"$ne": work.ID},
+		"deletedAt":  bson.M{"$exists": false},
+	}
+	running, err := db.Collection("works").Distinct(ctx, "_id", filter)
+	if err != nil || len(running) == 0 {
+		return nil, err
+	}
+
+	switch overlapPolicy() {
+	case overlapDisallow:
+		return nil, errWorkOverlap
+	case overlapPause:
+		ids := make([]primitive.ObjectID, 0, len(running))
+		for _, id := range running {
+			ids = append(ids, id.(primitive.ObjectID))
+		}
+		return ids, nil
+	default:
+		work.HasOverlap = true
+		return nil, nil
+	}
+}
+
+// pauseRunningWorks pauses the works returned by applyOverlapPolicy. It is
+// called after the new work was stored, so a failed insert pauses nothing.
+func pauseRunningWorks(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := db.Collection("works").UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "status": "in_progress"},
+		bson.M{"$set": bson.M{"status": "paused", "pausedAt": time.Now()}},
+	)
+	return err
+}
+
+// pausedMinutesUntil returns the total paused time of a work, counting a
+// pause that is still open up to the given time.
+func pausedMinutesUntil(work Work, t time.Time) int {
+	minutes := work.PausedMinutes
+	if work.PausedAt != nil && t.After(*work.PausedAt) {
+		minutes += int(t.Sub(*work.PausedAt).Minutes())
+	}
+	return minutes
+}
+
+func pauseWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("works").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": "in_progress", "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": "paused", "pausedAt": time.Now()}},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to pause work: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No running work found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Work paused successfully"})
+}
+
+func resumeWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var work Work
+	err = db.Collection("works").FindOne(ctx, bson.M{"_id": id, "status": "paused", "deletedAt": bson.M{"$exists": false}}).Decode(&work)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No paused work found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work: " + err.Error()})
+	}
+
+	toPause, err := applyOverlapPolicy(ctx, &work)
+	if err != nil {
+		if err == errWorkOverlap {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Devam eden başka bir işiniz var.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check running works: " + err.Error()})
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":        "in_progress",
+			"pausedMinutes": pausedMinutesUntil(work, now),
+			"hasOverlap":    work.HasOverlap,
+		},
+		"$unset": bson.M{"pausedAt": ""},
+	}
+	if work.PausedAt != nil {
+		update["$push"] = bson.M{"pauses": PauseInterval{Start: *work.PausedAt, End: now}}
+	}
+	_, err = db.Collection("works").UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resume work: " + err.Error()})
+	}
+	if err := pauseRunningWorks(ctx, toPause); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to pause running works: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Work resumed successfully"})
+}
+
+// getWorkOverlaps lists the intervals in which an employee had more than one
+// work running, so they can be cleaned up.
+func getWorkOverlaps(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	filter := bson.M{
+		"startTime": bson.M{"$lte": to},
+		"status":    bson.M{"$nin": []string{"rejected", "pending_approval"}},
+		"deletedAt": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"endTime": bson.M{"$gte": from}},
+			{"endTime": bson.M{"$exists": false}},
+		},
+	}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter["employeeId"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("works").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	sort.Slice(works, func(i, j int) bool {
+		if works[i].EmployeeID != works[j].EmployeeID {
+			return works[i].EmployeeID.Hex() < works[j].EmployeeID.Hex()
+		}
+		return works[i].StartTime.Before(works[j].StartTime)
+	})
+
+	now := time.Now()
+	overlaps := []WorkOverlap{}
+	for i, a := range works {
+		activeA := activeIntervals(a, now)
+		for _, b := range works[i+1:] {
+			if b.EmployeeID != a.EmployeeID || !b.StartTime.Before(workEnd(a, now)) {
+				break
+			}
+			// Paused time does not count, so works paused by the pause policy
+			// only overlap while both were actually running
+			for _, shared := range intersectIntervals(activeA, activeIntervals(b, now)) {
+				overlaps = append(overlaps, WorkOverlap{
+					EmployeeID:   a.EmployeeID,
+					EmployeeName: a.EmployeeName,
+					WorkIDs:      []primitive.ObjectID{a.ID, b.ID},
+					Start:        shared.Start,
+					End:          shared.End,
+					Minutes:      int(shared.End.Sub(shared.Start).Minutes()),
+				})
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": overlaps,
+	})
+}
+
+// timeRange is a half-open interval [Start, End).
+type timeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func workEnd(work Work, now time.Time) time.Time {
+	if work.EndTime.IsZero() {
+		return now
+	}
+	return work.EndTime
+}
+
+// activeIntervals returns the intervals in which a work was running, that is
+// its start to end without its pauses.
+func activeIntervals(work Work, now time.Time) []timeRange {
+	end := workEnd(work, now)
+	pauses := append([]PauseInterval{}, work.Pauses...)
+	if work.PausedAt != nil {
+		pauses = append(pauses, PauseInterval{Start: *work.PausedAt, End: end})
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].Start.Before(pauses[j].Start) })
+
+	intervals := []timeRange{}
+	start := work.StartTime
+	for _, pause := range pauses {
+		if pause.Start.After(start) {
+			intervals = append(intervals, timeRange{Start: start, End: minTime(pause.Start, end)})
+		}
+		if pause.End.After(start) {
+			start = pause.End
+		}
+	}
+	if end.After(start) {
+		intervals = append(intervals, timeRange{Start: start, End: end})
+	}
+	return intervals
+}
+
+// intersectIntervals returns the non-empty intersections of two sorted lists
+// of intervals.
+func intersectIntervals(a, b []timeRange) []timeRange {
+	shared := []timeRange{}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start := maxTime(a[i].Start, b[j].Start)
+		end := minTime(a[i].End, b[j].End)
+		if end.After(start) {
+			shared = append(shared, timeRange{Start: start, End: end})
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return shared
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
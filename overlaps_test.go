@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActiveIntervals(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 6, hour, minute, 0, 0, time.UTC)
+	}
+	pausedAt := at(11, 0)
+
+	tests := []struct {
+		name string
+		work Work
+		want []timeRange
+	}{
+		{
+			name: "no pauses",
+			work: Work{StartTime: at(9, 0), EndTime: at(10, 0)},
+			want: []timeRange{{at(9, 0), at(10, 0)}},
+		},
+		{
+			name: "finished pause",
+			work: Work{StartTime: at(9, 0), EndTime: at(12, 0), Pauses: []PauseInterval{{at(10, 0), at(11, 0)}}},
+			want: []timeRange{{at(9, 0), at(10, 0)}, {at(11, 0), at(12, 0)}},
+		},
+		{
+			name: "still paused",
+			work: Work{StartTime: at(9, 0), PausedAt: &pausedAt},
+			want: []timeRange{{at(9, 0), at(11, 0)}},
+		},
+		{
+			name: "unsorted pauses",
+			work: Work{StartTime: at(9, 0), EndTime: at(13, 0), Pauses: []PauseInterval{{at(11, 0), at(12, 0)}, {at(9, 30), at(10, 0)}}},
+			want: []timeRange{{at(9, 0), at(9, 30)}, {at(10, 0), at(11, 0)}, {at(12, 0), at(13, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := activeIntervals(tt.work, at(14, 0)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("activeIntervals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersectIntervals(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 6, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		a, b []timeRange
+		want []timeRange
+	}{
+		{"disjoint", []timeRange{{at(9), at(10)}}, []timeRange{{at(10), at(11)}}, []timeRange{}},
+		{"nested", []timeRange{{at(9), at(12)}}, []timeRange{{at(10), at(11)}}, []timeRange{{at(10), at(11)}}},
+		{
+			// The second work ran while the first was paused from 10 to 11
+			"paused by policy",
+			[]timeRange{{at(9), at(10)}, {at(11), at(12)}},
+			[]timeRange{{at(10), at(11)}},
+			[]timeRange{},
+		},
+		{
+			"several segments",
+			[]timeRange{{at(9), at(10)}, {at(11), at(13)}},
+			[]timeRange{{at(9), at(12)}},
+			[]timeRange{{at(9), at(10)}, {at(11), at(12)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intersectIntervals(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("intersectIntervals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
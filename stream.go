@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// streamHeartbeat keeps idle connections open through proxies.
+const streamHeartbeat = 25 * time.Second
+
+// streamTicketTTL is how long a stream ticket can be used to connect.
+const streamTicketTTL = time.Minute
+
+// StreamTicket lets one EventSource connect. EventSource cannot send headers,
+// so instead of a long-lived credential in the URL the page first asks for a
+// ticket with a normal request and connects with it. Tickets are used once.
+type StreamTicket struct {
+	Token      string             `bson:"_id"`
+	Admin      bool               `bson:"admin"`
+	EmployeeID primitive.ObjectID `bson:"employeeId,omitempty"`
+	ExpiresAt  time.Time          `bson:"expiresAt"`
+}
+
+// streamClient is one open Server-Sent Events connection. Admins receive
+// every event, employees only events about their own works and tasks plus
+// the video events that change the shared review queue.
+type streamClient struct {
+	events     chan Event
+	admin      bool
+	employeeID primitive.ObjectID
+}
+
+var (
+	streamClientsMu sync.Mutex
+	streamClients   = map[*streamClient]bool{}
+)
+
+func (client *streamClient) wants(event Event) bool {
+	if client.admin {
+		return true
+	}
+	switch data := event.Data.(type) {
+	case Work:
+		if data.EmployeeID == client.employeeID {
+			return true
+		}
+		switch event.Type {
+		case EventWorkCompleted:
+			return data.WorkType == "video" || data.WorkType == "revize"
+		case EventReviewSubmitted, EventVideoApproved:
+			return true
+		}
+	case Task:
+		return data.EmployeeID == client.employeeID
+	}
+	return false
+}
+
+// broadcastEvent is subscribed to the event bus and forwards events to the
+// connected clients. The bus calls it for one event at a time in publish
+// order, and every client has its own channel, so clients receive events in
+// order. Slow clients miss events instead of blocking others.
+func broadcastEvent(event Event) {
+	streamClientsMu.Lock()
+	defer streamClientsMu.Unlock()
+	for client := range streamClients {
+		if !client.wants(event) {
+			continue
+		}
+		select {
+		case client.events <- event:
+		default:
+		}
+	}
+}
+
+// createStreamTicket issues a ticket for the admin stream to requests with the
+// X-Admin-Token header, and for an employee's stream to requests with their
+// X-Employee-Id header.
+func createStreamTicket(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ticket := StreamTicket{Admin: isAdminToken(c.Get("X-Admin-Token")), ExpiresAt: time.Now().Add(streamTicketTTL)}
+	if !ticket.Admin {
+		id, err := primitive.ObjectIDFromHex(c.Get("X-Employee-Id"))
+		if err == nil {
+			err = db.Collection("employees").FindOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}).Err()
+		}
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin token or employee required",
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Canlı güncellemeler için personel seçiniz.",
+			})
+		}
+		ticket.EmployeeID = id
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate ticket: " + err.Error()})
+	}
+	ticket.Token = token
+	if _, err := db.Collection("stream_tickets").InsertOne(ctx, ticket); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create ticket: " + err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type": "success",
+		"data": fiber.Map{"ticket": ticket.Token, "expiresAt": ticket.ExpiresAt},
+	})
+}
+
+// streamEvents serves live updates as Server-Sent Events to the holder of a
+// ticket from createStreamTicket. Admin tickets get every event, employee
+// tickets the events of that employee.
+func streamEvents(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ticket StreamTicket
+	err := db.Collection("stream_tickets").FindOneAndDelete(ctx, bson.M{
+		"_id":       c.Query("ticket"),
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&ticket)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Invalid or expired stream ticket",
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Canlı güncelleme bileti geçersiz veya süresi dolmuş.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check stream ticket: " + err.Error()})
+	}
+	client := &streamClient{events: make(chan Event, 32), admin: ticket.Admin, employeeID: ticket.EmployeeID}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	streamClientsMu.Lock()
+	streamClients[client] = true
+	streamClientsMu.Unlock()
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			streamClientsMu.Lock()
+			delete(streamClients, client)
+			streamClientsMu.Unlock()
+		}()
+
+		// Tell the browser to retry after 3 seconds if the connection drops
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event := <-client.events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.Printf("Error encoding %s event for stream: %v", event.Type, err)
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID.Hex(), event.Type, data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}))
+	return nil
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Task struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EmployeeID      primitive.ObjectID `json:"employeeId" bson:"employeeId"`
+	EmployeeName    string             `json:"employeeName" bson:"employeeName"`
+	Title           string             `json:"title" bson:"title"`
+	Description     string             `json:"description" bson:"description"`
+	WorkType        string             `json:"workType" bson:"workType"`               // "software", "video", "review"
+	PlannedDate     time.Time          `json:"plannedDate" bson:"plannedDate"`         // Day the task is planned for
+	EstimateMinutes int                `json:"estimateMinutes" bson:"estimateMinutes"` // Planned effort
+	Status          string             `json:"status" bson:"status"`                   // "open", "in_progress" or "completed"
+	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type PlanComparison struct {
+	Date             string `json:"date"`
+	EmployeeID       string `json:"employeeId"`
+	EmployeeName     string `json:"employeeName"`
+	PlannedTasks     int    `json:"plannedTasks"`
+	CompletedTasks   int    `json:"completedTasks"`
+	CompletionRate   int    `json:"completionRate"` // Percentage of planned tasks completed
+	PlannedMinutes   int    `json:"plannedMinutes"`
+	ActualMinutes    int    `json:"actualMinutes"`    // Time spent on planned tasks
+	OffPlanMinutes   int    `json:"offPlanMinutes"`   // Time spent on tasks planned for a day outside the range
+	UnplannedMinutes int    `json:"unplannedMinutes"` // Time spent on works without a task
+}
+
+// parseDateRange reads the "from" and "to" query parameters (YYYY-MM-DD) and
+// returns the start of the first day and the end of the last day. Both
+// default to today.
+func parseDateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	today := time.Now().Format("2006-01-02")
+	from, err := time.ParseInLocation("2006-01-02", c.Query("from", today), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := time.ParseInLocation("2006-01-02", c.Query("to", c.Query("from", today)), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func createTask(c *fiber.Ctx) error {
+	var task Task
+	if err := c.BodyParser(&task); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if task.Title == "" || task.EmployeeID.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Task title and employee are required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen görev başlığını ve personeli giriniz.",
+		})
+	}
+	if task.EstimateMinutes < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Estimate cannot be negative",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Tahmini süre negatif olamaz.",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var employee Employee
+	err := db.Collection("employees").FindOne(ctx, bson.M{
+		"_id":       task.EmployeeID,
+		"deletedAt": bson.M{"$exists": false},
+	}).Decode(&employee)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Employee not found",
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Personel bulunamadı",
+		})
+	}
+
+	if task.PlannedDate.IsZero() {
+		task.PlannedDate = time.Now()
+	}
+	task.PlannedDate = time.Date(task.PlannedDate.Year(), task.PlannedDate.Month(), task.PlannedDate.Day(), 0, 0, 0, 0, time.Local)
+	task.ID = primitive.NewObjectID()
+	task.EmployeeName = employee.Name
+	task.Status = "open"
+	task.CreatedAt = time.Now()
+
+	if _, err := db.Collection("tasks").InsertOne(ctx, task); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create task: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Görev eklenirken bir hata oluştu.",
+		})
+	}
+	publishEvent(EventTaskAssigned, task)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Görev başarıyla eklendi.",
+		"data":  task,
+	})
+}
+
+func getTasks(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter["employeeId"] = id
+	}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"type":  "error",
+				"title": "Hata",
+				"text":  "Geçersiz tarih formatı",
+			})
+		}
+		filter["plannedDate"] = bson.M{"$gte": from, "$lte": to}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("tasks").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch tasks: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []Task{}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode tasks: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": tasks,
+	})
+}
+
+func updateTask(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		Title           string    `json:"title"`
+		Description     string    `json:"description"`
+		WorkType        string    `json:"workType"`
+		PlannedDate     time.Time `json:"plannedDate"`
+		EstimateMinutes int       `json:"estimateMinutes"`
+		Status          string    `json:"status"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updateFields := bson.M{}
+	if update.Title != "" {
+		updateFields["title"] = update.Title
+	}
+	if update.Description != "" {
+		updateFields["description"] = update.Description
+	}
+	if update.WorkType != "" {
+		updateFields["workType"] = update.WorkType
+	}
+	if !update.PlannedDate.IsZero() {
+		updateFields["plannedDate"] = time.Date(update.PlannedDate.Year(), update.PlannedDate.Month(), update.PlannedDate.Day(), 0, 0, 0, 0, time.Local)
+	}
+	if update.EstimateMinutes > 0 {
+		updateFields["estimateMinutes"] = update.EstimateMinutes
+	}
+	if update.Status != "" {
+		if update.Status != "open" && update.Status != "in_progress" && update.Status != "completed" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task status"})
+		}
+		updateFields["status"] = update.Status
+	}
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Nothing to update"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("tasks").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update task: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Task updated successfully"})
+}
+
+func deleteTask(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Tasks that already have works keep them; only the plan entry is removed
+	result, err := db.Collection("tasks").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete task: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Task deleted successfully"})
+}
+
+// startTask creates an in-progress work from a task and marks the task as started.
+func startTask(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var task Task
+	err = db.Collection("tasks").FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch task: " + err.Error()})
+	}
+	if task.Status == "completed" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Task already completed",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Bu görev zaten tamamlanmış.",
+		})
+	}
+
+	work := Work{
+		ID:           primitive.NewObjectID(),
+		EmployeeID:   task.EmployeeID,
+		EmployeeName: task.EmployeeName,
+		WorkType:     task.WorkType,
+		Description:  task.Title,
+		TaskID:       task.ID,
+		Reviews:      []Review{},
+		StartTime:    time.Now(),
+		Status:       "in_progress",
+	}
+	if task.Description != "" {
+		work.Description = task.Title + ": " + task.Description
+	}
+
+	toPause, err := applyOverlapPolicy(ctx, &work)
+	if err != nil {
+		if err == errWorkOverlap {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+				"type":  "warning",
+				"title": "Uyarı",
+				"text":  "Devam eden başka bir işiniz var. Lütfen önce onu tamamlayınız.",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check running works: " + err.Error()})
+	}
+
+	if _, err := db.Collection("works").InsertOne(ctx, work); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create work: " + err.Error()})
+	}
+	if err := pauseRunningWorks(ctx, toPause); err != nil {
+		log.Printf("Error pausing running works: %v", err)
+	}
+
+	if task.Status == "open" {
+		_, err = db.Collection("tasks").UpdateOne(ctx, bson.M{"_id": task.ID}, bson.M{"$set": bson.M{"status": "in_progress"}})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update task: " + err.Error()})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(work)
+}
+
+// getPlanComparison compares planned tasks with the time actually logged,
+// grouped per day and per employee.
+func getPlanComparison(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	taskFilter := bson.M{"plannedDate": bson.M{"$gte": from, "$lte": to}}
+	workFilter := bson.M{
+		"startTime": bson.M{"$gte": from, "$lte": to},
+		"status":    "completed",
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		taskFilter["employeeId"] = id
+		workFilter["employeeId"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	taskCursor, err := db.Collection("tasks").Find(ctx, taskFilter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch tasks: " + err.Error()})
+	}
+	defer taskCursor.Close(ctx)
+
+	var tasks []Task
+	if err = taskCursor.All(ctx, &tasks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode tasks: " + err.Error()})
+	}
+
+	workCursor, err := db.Collection("works").Find(ctx, workFilter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer workCursor.Close(ctx)
+
+	var works []Work
+	if err = workCursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	// Works on tasks planned outside the range still belong to a plan
+	var otherTaskIDs []primitive.ObjectID
+	inRange := map[primitive.ObjectID]bool{}
+	for _, task := range tasks {
+		inRange[task.ID] = true
+	}
+	for _, work := range works {
+		if !work.TaskID.IsZero() && !inRange[work.TaskID] {
+			inRange[work.TaskID] = true
+			otherTaskIDs = append(otherTaskIDs, work.TaskID)
+		}
+	}
+	otherTaskDays := map[primitive.ObjectID]time.Time{}
+	if len(otherTaskIDs) > 0 {
+		otherCursor, err := db.Collection("tasks").Find(ctx, bson.M{"_id": bson.M{"$in": otherTaskIDs}},
+			options.Find().SetProjection(bson.M{"plannedDate": 1}))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch tasks: " + err.Error()})
+		}
+		defer otherCursor.Close(ctx)
+
+		var otherTasks []Task
+		if err = otherCursor.All(ctx, &otherTasks); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode tasks: " + err.Error()})
+		}
+		for _, task := range otherTasks {
+			otherTaskDays[task.ID] = task.PlannedDate
+		}
+	}
+
+	comparison := comparePlans(tasks, works, otherTaskDays)
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": comparison,
+	})
+}
+
+// comparePlans groups planned tasks and logged works per day and employee.
+// Time on a task planned in the range counts towards its planned day; time on
+// a task planned outside the range (otherTaskDays) is off-plan work on the day
+// it was done, and works without a known task are unplanned.
+func comparePlans(tasks []Task, works []Work, otherTaskDays map[primitive.ObjectID]time.Time) []PlanComparison {
+	rows := map[string]*PlanComparison{}
+	var keys []string
+	row := func(date time.Time, employeeID primitive.ObjectID, employeeName string) *PlanComparison {
+		day := date.In(time.Local).Format("2006-01-02")
+		key := day + "|" + employeeID.Hex()
+		if r, ok := rows[key]; ok {
+			return r
+		}
+		r := &PlanComparison{Date: day, EmployeeID: employeeID.Hex(), EmployeeName: employeeName}
+		rows[key] = r
+		keys = append(keys, key)
+		return r
+	}
+
+	taskDays := map[primitive.ObjectID]time.Time{}
+	for _, task := range tasks {
+		r := row(task.PlannedDate, task.EmployeeID, task.EmployeeName)
+		r.PlannedTasks++
+		r.PlannedMinutes += task.EstimateMinutes
+		if task.Status == "completed" {
+			r.CompletedTasks++
+		}
+		taskDays[task.ID] = task.PlannedDate
+	}
+
+	for _, work := range works {
+		if work.TaskID.IsZero() {
+			row(work.StartTime, work.EmployeeID, work.EmployeeName).UnplannedMinutes += work.DurationMinutes
+			continue
+		}
+		if plannedDate, ok := taskDays[work.TaskID]; ok {
+			row(plannedDate, work.EmployeeID, work.EmployeeName).ActualMinutes += work.DurationMinutes
+			continue
+		}
+		if _, ok := otherTaskDays[work.TaskID]; ok {
+			row(work.StartTime, work.EmployeeID, work.EmployeeName).OffPlanMinutes += work.DurationMinutes
+			continue
+		}
+		// The task was deleted, so nothing is left of the plan
+		row(work.StartTime, work.EmployeeID, work.EmployeeName).UnplannedMinutes += work.DurationMinutes
+	}
+
+	sort.Strings(keys)
+	comparison := make([]PlanComparison, 0, len(keys))
+	for _, key := range keys {
+		r := rows[key]
+		if r.PlannedTasks > 0 {
+			r.CompletionRate = r.CompletedTasks * 100 / r.PlannedTasks
+		}
+		comparison = append(comparison, *r)
+	}
+	return comparison
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComparePlans(t *testing.T) {
+	employee := primitive.NewObjectID()
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.Local) }
+	planned := Task{ID: primitive.NewObjectID(), EmployeeID: employee, EmployeeName: "Ayşe", PlannedDate: day(6), EstimateMinutes: 60, Status: "completed"}
+	open := Task{ID: primitive.NewObjectID(), EmployeeID: employee, EmployeeName: "Ayşe", PlannedDate: day(6), EstimateMinutes: 30, Status: "open"}
+	earlier := primitive.NewObjectID()
+	deleted := primitive.NewObjectID()
+
+	works := []Work{
+		// Done the day after it was planned, still counted on the planned day
+		{EmployeeID: employee, EmployeeName: "Ayşe", TaskID: planned.ID, StartTime: day(7).Add(10 * time.Hour), DurationMinutes: 50},
+		{EmployeeID: employee, EmployeeName: "Ayşe", TaskID: earlier, StartTime: day(7).Add(11 * time.Hour), DurationMinutes: 20},
+		{EmployeeID: employee, EmployeeName: "Ayşe", StartTime: day(7).Add(12 * time.Hour), DurationMinutes: 15},
+		{EmployeeID: employee, EmployeeName: "Ayşe", TaskID: deleted, StartTime: day(7).Add(13 * time.Hour), DurationMinutes: 5},
+	}
+	otherTaskDays := map[primitive.ObjectID]time.Time{earlier: day(1)}
+
+	want := []PlanComparison{
+		{
+			Date:           "2024-05-06",
+			EmployeeID:     employee.Hex(),
+			EmployeeName:   "Ayşe",
+			PlannedTasks:   2,
+			CompletedTasks: 1,
+			CompletionRate: 50,
+			PlannedMinutes: 90,
+			ActualMinutes:  50,
+		},
+		{
+			Date:             "2024-05-07",
+			EmployeeID:       employee.Hex(),
+			EmployeeName:     "Ayşe",
+			OffPlanMinutes:   20,
+			UnplannedMinutes: 20,
+		},
+	}
+	if got := comparePlans([]Task{planned, open}, works, otherTaskDays); !reflect.DeepEqual(got, want) {
+		t.Errorf("comparePlans() = %+v, want %+v", got, want)
+	}
+}
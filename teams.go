@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Team struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name       string             `json:"name" bson:"name"`
+	Department string             `json:"department" bson:"department"`
+	LeadID     primitive.ObjectID `json:"leadId,omitempty" bson:"leadId,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type TeamStats struct {
+	TeamID       primitive.ObjectID `json:"teamId"`
+	TeamName     string             `json:"teamName"`
+	Department   string             `json:"department"`
+	Members      int                `json:"members"`
+	TotalMinutes int                `json:"totalMinutes"`
+	WorkStats                       // Same averages as the employee stats
+}
+
+type EmployeeTimeline struct {
+	Employee Employee        `json:"employee"`
+	Timeline []TimelineSlot  `json:"timeline"`
+	Day      DayAvailability `json:"day"`
+}
+
+func createTeam(c *fiber.Ctx) error {
+	var team Team
+	if err := c.BodyParser(&team); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if team.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Team name is required",
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Lütfen takım adını giriniz.",
+		})
+	}
+
+	team.ID = primitive.NewObjectID()
+	team.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Collection("teams").InsertOne(ctx, team); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create team: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Takım eklenirken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Takım başarıyla eklendi.",
+		"data":  team,
+	})
+}
+
+func getTeams(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if department := c.Query("department"); department != "" {
+		filter["department"] = department
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("teams").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch teams: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	teams := []Team{}
+	if err = cursor.All(ctx, &teams); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode teams: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": teams,
+	})
+}
+
+func updateTeam(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		Name       string              `json:"name"`
+		Department *string             `json:"department"`
+		LeadID     *primitive.ObjectID `json:"leadId"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updateFields := bson.M{}
+	if update.Name != "" {
+		updateFields["name"] = update.Name
+	}
+	if update.Department != nil {
+		updateFields["department"] = *update.Department
+	}
+	if update.LeadID != nil {
+		updateFields["leadId"] = *update.LeadID
+	}
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Nothing to update"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("teams").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update team: " + err.Error()})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Team not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Team updated successfully"})
+}
+
+func deleteTeam(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("teams").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete team: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Team not found"})
+	}
+
+	// Members stay, they are only detached from the team
+	_, err = db.Collection("employees").UpdateMany(ctx, bson.M{"teamId": id}, bson.M{"$unset": bson.M{"teamId": ""}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to detach team members: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Team deleted successfully"})
+}
+
+// getTeamStats sums the completed works of each team's members in the given
+// date range. Employees without a team are grouped under a zero team ID.
+func getTeamStats(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var teams []Team
+	teamCursor, err := db.Collection("teams").Find(ctx, bson.M{})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch teams: " + err.Error()})
+	}
+	defer teamCursor.Close(ctx)
+	if err = teamCursor.All(ctx, &teams); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode teams: " + err.Error()})
+	}
+
+	var employees []Employee
+	employeeCursor, err := db.Collection("employees").Find(ctx, bson.M{})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employees: " + err.Error()})
+	}
+	defer employeeCursor.Close(ctx)
+	if err = employeeCursor.All(ctx, &employees); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode employees: " + err.Error()})
+	}
+
+	var works []Work
+	workCursor, err := db.Collection("works").Find(ctx, bson.M{
+		"status":    "completed",
+		"startTime": bson.M{"$gte": from, "$lte": to},
+		"deletedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer workCursor.Close(ctx)
+	if err = workCursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	stats := make([]TeamStats, 0, len(teams)+1)
+	index := map[primitive.ObjectID]int{}
+	for _, team := range teams {
+		index[team.ID] = len(stats)
+		stats = append(stats, TeamStats{TeamID: team.ID, TeamName: team.Name, Department: team.Department})
+	}
+	index[primitive.NilObjectID] = len(stats)
+	stats = append(stats, TeamStats{TeamName: "Takımsız"})
+
+	teamOf := map[primitive.ObjectID]int{}
+	for _, employee := range employees {
+		i, ok := index[employee.TeamID]
+		if !ok {
+			i = index[primitive.NilObjectID]
+		}
+		teamOf[employee.ID] = i
+		if employee.DeletedAt == nil {
+			stats[i].Members++
+		}
+	}
+
+	teamWorks := make([][]Work, len(stats))
+	for _, work := range works {
+		i, ok := teamOf[work.EmployeeID]
+		if !ok {
+			i = index[primitive.NilObjectID]
+		}
+		teamWorks[i] = append(teamWorks[i], work)
+		stats[i].TotalMinutes += work.DurationMinutes
+	}
+	for i := range stats {
+		stats[i].WorkStats = workStats(teamWorks[i])
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": stats,
+	})
+}
+
+// getTeamTimeline returns the daily timeline of every member of a team.
+func getTeamTimeline(c *fiber.Ctx) error {
+	teamID, err := primitive.ObjectIDFromHex(c.Query("teamId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz takım ID formatı",
+		})
+	}
+
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("2006-01-02")
+	}
+
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var members []Employee
+	cursor, err := db.Collection("employees").Find(ctx, bson.M{"teamId": teamID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Takım üyeleri yüklenirken bir hata oluştu",
+		})
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &members); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Takım üyeleri yüklenirken bir hata oluştu",
+		})
+	}
+
+	memberIDs := []primitive.ObjectID{}
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.ID)
+	}
+
+	var works []Work
+	workCursor, err := db.Collection("works").Find(ctx, bson.M{
+		"startTime": bson.M{
+			"$gte": time.Date(date.Year(), date.Month(), date.Day(), 9, 0, 0, 0, time.Local),
+			"$lte": time.Date(date.Year(), date.Month(), date.Day(), 18, 0, 0, 0, time.Local),
+		},
+		"employeeId": bson.M{"$in": memberIDs},
+		"deletedAt":  bson.M{"$exists": false},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "İşler yüklenirken bir hata oluştu",
+		})
+	}
+	defer workCursor.Close(ctx)
+	if err = workCursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "İşler yüklenirken bir hata oluştu",
+		})
+	}
+
+	timelines := []EmployeeTimeline{}
+	for _, member := range members {
+		// Same rule as the employee timeline: nothing after the deletion day
+		if member.DeletedAt != nil && date.After(member.DeletedAt.Truncate(24*time.Hour)) {
+			continue
+		}
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+		days, err := availability(ctx, member, day, day)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"type":  "error",
+				"title": "Hata",
+				"text":  "İzin bilgileri yüklenirken bir hata oluştu",
+			})
+		}
+		timelines = append(timelines, EmployeeTimeline{
+			Employee: member,
+			Timeline: buildTimeline(member.ID, works),
+			Day:      days[0],
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": timelines,
+	})
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+var turkishMonths = [...]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"}
+
+// TimesheetApprovals summarises the review and approval state of the works in
+// a monthly timesheet.
+type TimesheetApprovals struct {
+	Videos          int
+	ApprovedVideos  int
+	ReviewedVideos  int
+	PendingVideos   int
+	ManualEntries   int
+	ManualApproved  int
+	ManualRejected  int
+	ManualPending   int
+	CompletedWorks  int
+	CompletedByType map[string]int
+}
+
+func timesheetApprovals(works []Work) TimesheetApprovals {
+	approvals := TimesheetApprovals{CompletedByType: map[string]int{}}
+	for _, work := range works {
+		if work.Status == "completed" {
+			approvals.CompletedWorks++
+			approvals.CompletedByType[work.WorkType]++
+		}
+		if work.WorkType == "video" && work.Status == "completed" {
+			approvals.Videos++
+			switch {
+			case work.RevisionStatus == "approved":
+				approvals.ApprovedVideos++
+			case work.IsReviewed:
+				approvals.ReviewedVideos++
+			default:
+				approvals.PendingVideos++
+			}
+		}
+		if work.ManualEntry != nil {
+			approvals.ManualEntries++
+			switch work.ManualEntry.Status {
+			case "approved":
+				approvals.ManualApproved++
+			case "rejected":
+				approvals.ManualRejected++
+			default:
+				approvals.ManualPending++
+			}
+		}
+	}
+	return approvals
+}
+
+// fitText shortens s with an ellipsis so it fits in a cell of the given width.
+func fitText(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// renderTimesheetPDF lays out the monthly timesheet on A4 pages. The Go fonts
+// are embedded so Turkish characters render without system fonts.
+func renderTimesheetPDF(employee Employee, month time.Time, days []TimesheetDay, works []Work) *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("Go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("Go", "B", gobold.TTF)
+	pdf.SetTitle(fmt.Sprintf("Aylık Puantaj - %s", employee.Name), true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-13)
+		pdf.SetFont("Go", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Oluşturulma: %s  ·  Sayfa %d/{nb}", time.Now().Format(exportDateTimeFormat), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	period := fmt.Sprintf("%s %d", turkishMonths[month.Month()-1], month.Year())
+	employeeType := "Personel"
+	if employee.Type == "intern" {
+		employeeType = "Stajyer"
+	}
+
+	pdf.SetFont("Go", "B", 16)
+	pdf.CellFormat(0, 9, "Aylık Puantaj Raporu", "", 1, "L", false, 0, "")
+	pdf.SetFont("Go", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("%s: %s", employeeType, employee.Name), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Dönem: "+period, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	section := func(title string) {
+		pdf.Ln(2)
+		pdf.SetFont("Go", "B", 11)
+		pdf.CellFormat(0, 7, title, "", 1, "L", false, 0, "")
+	}
+	header := func(widths []float64, labels []string) {
+		pdf.SetFont("Go", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for i, label := range labels {
+			pdf.CellFormat(widths[i], 6, label, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Go", "", 8)
+	}
+
+	// Daily totals
+	section("Günlük Toplamlar")
+	dayWidths := []float64{22, 24, 24, 18, 22, 22, 24, 24}
+	dayLabels := []string{"Tarih", "Gün", "Gün Türü", "İş Sayısı", "İlk Başlangıç", "Son Bitiş", "Çalışılan", "Beklenen"}
+	header(dayWidths, dayLabels)
+	kinds := map[string]string{"workday": "İş günü", "weekend": "Hafta sonu", "holiday": "Tatil", "leave": "İzin"}
+	totalMinutes, expectedMinutes := 0, 0
+	for _, day := range days {
+		if pdf.GetY() > 270 {
+			pdf.AddPage()
+			header(dayWidths, dayLabels)
+		}
+		fill := day.Kind != "workday"
+		pdf.SetFillColor(245, 245, 245)
+		cells := []string{
+			day.Date.Format(exportDateFormat),
+			turkishWeekdays[day.Date.Weekday()],
+			label(kinds, day.Kind),
+			strconv.Itoa(day.Works),
+			formatExportTime(day.FirstStart, "15:04"),
+			formatExportTime(day.LastEnd, "15:04"),
+			formatDuration(day.Minutes),
+			formatDuration(day.ExpectedMinutes),
+		}
+		for i, cell := range cells {
+			pdf.CellFormat(dayWidths[i], 5, cell, "1", 0, "C", fill, 0, "")
+		}
+		pdf.Ln(-1)
+		totalMinutes += day.Minutes
+		expectedMinutes += day.ExpectedMinutes
+	}
+	pdf.SetFont("Go", "B", 8)
+	pdf.CellFormat(dayWidths[0]+dayWidths[1]+dayWidths[2]+dayWidths[3]+dayWidths[4]+dayWidths[5], 6, "Toplam", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(dayWidths[6], 6, formatDuration(totalMinutes), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(dayWidths[7], 6, formatDuration(expectedMinutes), "1", 1, "C", false, 0, "")
+
+	// Work list
+	section("İş Listesi")
+	workWidths := []float64{26, 20, 78, 26, 30}
+	workLabels := []string{"Başlangıç", "İş Türü", "Açıklama", "Süre", "Durum"}
+	header(workWidths, workLabels)
+	if len(works) == 0 {
+		pdf.CellFormat(0, 5, "Bu dönemde iş kaydı bulunmuyor.", "1", 1, "C", false, 0, "")
+	}
+	for _, work := range works {
+		if pdf.GetY() > 270 {
+			pdf.AddPage()
+			header(workWidths, workLabels)
+		}
+		description := work.Description
+		if work.IsManual {
+			description += " (manuel)"
+		}
+		cells := []string{
+			formatExportTime(work.StartTime, exportDateTimeFormat),
+			label(workTypeLabels, work.WorkType),
+			fitText(pdf, description, workWidths[2]-2),
+			formatDuration(work.DurationMinutes),
+			label(workStatusLabels, work.Status),
+		}
+		for i, cell := range cells {
+			align := "C"
+			if i == 2 {
+				align = "L"
+			}
+			pdf.CellFormat(workWidths[i], 5, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Approval statistics
+	approvals := timesheetApprovals(works)
+	section("Onay İstatistikleri")
+	pdf.SetFont("Go", "", 9)
+	lines := []string{
+		fmt.Sprintf("Tamamlanan iş: %d (Yazılım: %d, Video: %d, İnceleme: %d, Revize: %d)",
+			approvals.CompletedWorks, approvals.CompletedByType["software"], approvals.CompletedByType["video"],
+			approvals.CompletedByType["review"], approvals.CompletedByType["revize"]),
+		fmt.Sprintf("Videolar: %d onaylandı, %d incelendi, %d inceleme bekliyor", approvals.ApprovedVideos, approvals.ReviewedVideos, approvals.PendingVideos),
+		fmt.Sprintf("Manuel kayıtlar: %d onaylandı, %d reddedildi, %d onay bekliyor", approvals.ManualApproved, approvals.ManualRejected, approvals.ManualPending),
+	}
+	for _, line := range lines {
+		pdf.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+	}
+
+	// Signature lines
+	if pdf.GetY() > 240 {
+		pdf.AddPage()
+	}
+	pdf.Ln(14)
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+	columnWidth := (pageWidth - left - right - 20) / 2
+	y := pdf.GetY()
+	signatures := []string{employeeType, "Sorumlu / Yönetici"}
+	for i, title := range signatures {
+		x := left + float64(i)*(columnWidth+20)
+		pdf.Line(x, y+12, x+columnWidth, y+12)
+		pdf.SetXY(x, y+13)
+		pdf.SetFont("Go", "B", 9)
+		pdf.CellFormat(columnWidth, 5, title, "", 2, "C", false, 0, "")
+		pdf.SetFont("Go", "", 8)
+		pdf.CellFormat(columnWidth, 5, "Ad Soyad / Tarih / İmza", "", 0, "C", false, 0, "")
+	}
+	return pdf
+}
+
+// getTimesheetPDF returns an employee's monthly timesheet as a printable PDF.
+// The month is given as YYYY-MM and defaults to the current month.
+func getTimesheetPDF(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+	}
+	month, err := time.ParseInLocation("2006-01", c.Query("month", time.Now().Format("2006-01")), time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz ay formatı (YYYY-AA)",
+		})
+	}
+	from, to := month, month.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var employee Employee
+	if err := db.Collection("employees").FindOne(ctx, bson.M{"_id": id}).Decode(&employee); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employee: " + err.Error()})
+	}
+
+	days, err := employeeTimesheet(ctx, employee, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compute timesheet: " + err.Error()})
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": id,
+		"startTime":  bson.M{"$gte": from, "$lte": to},
+		"deletedAt":  bson.M{"$exists": false},
+	}, options.Find().SetSort(bson.M{"startTime": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	works := []Work{}
+	if err = cursor.All(ctx, &works); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode works: " + err.Error()})
+	}
+
+	pdf := renderTimesheetPDF(employee, month, days, works)
+	if err := pdf.Error(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to render PDF: " + err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="puantaj-%s.pdf"`, month.Format("2006-01")))
+	return pdf.Output(c.Response().BodyWriter())
+}
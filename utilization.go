@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// utilizationToleranceMinutes is how far logged time may differ from the
+// expected hours before a day or week is flagged.
+const utilizationToleranceMinutes = 30
+
+type UtilizationPeriod struct {
+	Period          string `json:"period"` // "2024-05-06" for days, "2024-W19" for weeks
+	Kind            string `json:"kind,omitempty"`
+	ExpectedMinutes int    `json:"expectedMinutes"`
+	LoggedMinutes   int    `json:"loggedMinutes"`
+	DiffMinutes     int    `json:"diffMinutes"` // Positive for overtime
+	Flag            string `json:"flag"`        // "ok", "overtime", "undertime" or "no_work"
+}
+
+type UtilizationReport struct {
+	EmployeeID      primitive.ObjectID  `json:"employeeId"`
+	EmployeeName    string              `json:"employeeName"`
+	WeeklyHours     float64             `json:"weeklyHours"`
+	ExpectedMinutes int                 `json:"expectedMinutes"`
+	LoggedMinutes   int                 `json:"loggedMinutes"`
+	UtilizationRate int                 `json:"utilizationRate"` // Logged time as a percentage of expected time
+	OvertimeDays    int                 `json:"overtimeDays"`
+	UndertimeDays   int                 `json:"undertimeDays"`
+	NoWorkDays      int                 `json:"noWorkDays"`
+	Days            []UtilizationPeriod `json:"days"`
+	Weeks           []UtilizationPeriod `json:"weeks"`
+}
+
+func utilizationFlag(expected, logged int) string {
+	switch {
+	case expected > 0 && logged == 0:
+		return "no_work"
+	case logged > expected+utilizationToleranceMinutes:
+		return "overtime"
+	case logged < expected-utilizationToleranceMinutes:
+		return "undertime"
+	default:
+		return "ok"
+	}
+}
+
+// employeeUtilization compares an employee's completed works with their
+// expected hours, day by day and per ISO week.
+func employeeUtilization(ctx context.Context, employee Employee, from, to time.Time) (UtilizationReport, error) {
+	report := UtilizationReport{
+		EmployeeID:   employee.ID,
+		EmployeeName: employee.Name,
+		WeeklyHours:  employee.WeeklyHours,
+		Days:         []UtilizationPeriod{},
+		Weeks:        []UtilizationPeriod{},
+	}
+	if report.WeeklyHours == 0 {
+		report.WeeklyHours = defaultWeeklyHours
+	}
+
+	days, err := availability(ctx, employee, from, to)
+	if err != nil {
+		return report, err
+	}
+
+	cursor, err := db.Collection("works").Find(ctx, bson.M{
+		"employeeId": employee.ID,
+		"status":     "completed",
+		"startTime":  bson.M{"$gte": from, "$lte": to},
+		"deletedAt":  bson.M{"$exists": false},
+	})
+	if err != nil {
+		return report, err
+	}
+	var works []Work
+	if err = cursor.All(ctx, &works); err != nil {
+		return report, err
+	}
+
+	logged := map[string]int{}
+	for _, work := range works {
+		logged[work.StartTime.In(time.Local).Format("2006-01-02")] += work.DurationMinutes
+	}
+
+	weekIndex := map[string]int{}
+	for _, day := range days {
+		entry := UtilizationPeriod{
+			Period:          day.Date,
+			Kind:            day.Kind,
+			ExpectedMinutes: day.ExpectedMinutes,
+			LoggedMinutes:   logged[day.Date],
+		}
+		entry.DiffMinutes = entry.LoggedMinutes - entry.ExpectedMinutes
+		entry.Flag = utilizationFlag(entry.ExpectedMinutes, entry.LoggedMinutes)
+		switch entry.Flag {
+		case "overtime":
+			report.OvertimeDays++
+		case "undertime":
+			report.UndertimeDays++
+		case "no_work":
+			report.NoWorkDays++
+		}
+		report.Days = append(report.Days, entry)
+		report.ExpectedMinutes += entry.ExpectedMinutes
+		report.LoggedMinutes += entry.LoggedMinutes
+
+		date, _ := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		year, week := date.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		i, ok := weekIndex[key]
+		if !ok {
+			i = len(report.Weeks)
+			weekIndex[key] = i
+			report.Weeks = append(report.Weeks, UtilizationPeriod{Period: key})
+		}
+		report.Weeks[i].ExpectedMinutes += entry.ExpectedMinutes
+		report.Weeks[i].LoggedMinutes += entry.LoggedMinutes
+	}
+
+	for i := range report.Weeks {
+		week := &report.Weeks[i]
+		week.DiffMinutes = week.LoggedMinutes - week.ExpectedMinutes
+		week.Flag = utilizationFlag(week.ExpectedMinutes, week.LoggedMinutes)
+	}
+	if report.ExpectedMinutes > 0 {
+		report.UtilizationRate = report.LoggedMinutes * 100 / report.ExpectedMinutes
+	}
+	return report, nil
+}
+
+// getUtilization reports logged time against contracted hours for one
+// employee, a team, or every active employee.
+func getUtilization(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Geçersiz tarih formatı",
+		})
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Rapor aralığı en fazla bir yıl olabilir.",
+		})
+	}
+
+	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	for _, field := range []string{"employeeId", "teamId"} {
+		if value := c.Query(field); value != "" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + field + " format"})
+			}
+			if field == "employeeId" {
+				filter = bson.M{"_id": id}
+			} else {
+				filter["teamId"] = id
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("employees").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch employees: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	var employees []Employee
+	if err = cursor.All(ctx, &employees); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode employees: " + err.Error()})
+	}
+
+	reports := []UtilizationReport{}
+	for _, employee := range employees {
+		report, err := employeeUtilization(ctx, employee, from, to)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compute utilization: " + err.Error()})
+		}
+		reports = append(reports, report)
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": reports,
+	})
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Deliveries are retried with exponential backoff: 1s, 2s, 4s, 8s...
+const (
+	webhookMaxAttempts    = 6
+	webhookInitialBackoff = time.Second
+)
+
+// Queued deliveries are picked up by webhookWorkers workers. A claimed
+// delivery is hidden from the others for webhookLease, so a worker that dies
+// mid-request only delays it.
+const (
+	webhookWorkers      = 4
+	webhookPollInterval = time.Second
+	webhookLease        = time.Minute
+)
+
+var errInternalAddress = errors.New("webhook URLs must not point to internal addresses")
+
+// webhookClient refuses to connect to internal addresses, which also covers
+// redirects and host names that resolve differently after validation.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+					return errInternalAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+type Webhook struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL       string             `json:"url" bson:"url"`
+	Secret    string             `json:"secret,omitempty" bson:"secret"` // Only returned when the webhook is created
+	Events    []string           `json:"events" bson:"events"`
+	Active    bool               `json:"active" bson:"active"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type DeliveryAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs"`
+}
+
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookID     primitive.ObjectID `json:"webhookId" bson:"webhookId"`
+	EventID       primitive.ObjectID `json:"eventId" bson:"eventId"`
+	Event         string             `json:"event" bson:"event"`
+	Payload       string             `json:"payload" bson:"payload"`
+	Status        string             `json:"status" bson:"status"` // "pending", "succeeded" or "failed"
+	Attempts      []DeliveryAttempt  `json:"attempts" bson:"attempts"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	CompletedAt   *time.Time         `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	NextAttemptAt *time.Time         `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"` // Only set while pending
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", so
+// receivers can reject replayed payloads by checking the timestamp.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// dispatchWebhooks queues a delivery of the event for every active webhook
+// subscribed to it.
+func dispatchWebhooks(event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("webhooks").Find(ctx, bson.M{"active": true, "events": event.Type})
+	if err != nil {
+		log.Printf("Error fetching webhooks for %s: %v", event.Type, err)
+		return
+	}
+	var webhooks []Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		log.Printf("Error decoding webhooks for %s: %v", event.Type, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", event.Type, err)
+		return
+	}
+	now := time.Now()
+	for _, webhook := range webhooks {
+		delivery := WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			Event:         event.Type,
+			Payload:       string(payload),
+			Status:        "pending",
+			Attempts:      []DeliveryAttempt{},
+			CreatedAt:     now,
+			NextAttemptAt: &now,
+		}
+		// A replayed event is already queued
+		_, err := db.Collection("webhook_deliveries").InsertOne(ctx, delivery)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			log.Printf("Error queueing webhook delivery: %v", err)
+		}
+	}
+}
+
+// runWebhookDeliveries starts the workers that send queued deliveries. The
+// queue lives in webhook_deliveries, so pending retries survive a restart.
+func runWebhookDeliveries() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err := db.Collection("webhook_deliveries").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+	})
+	cancel()
+	if err != nil {
+		log.Printf("Error creating webhook delivery index: %v", err)
+	}
+
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for {
+				if !deliverNextWebhook() {
+					time.Sleep(webhookPollInterval)
+				}
+			}
+		}()
+	}
+}
+
+// deliverNextWebhook claims the next due delivery and makes one attempt. It
+// returns false when nothing was due.
+func deliverNextWebhook() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var delivery WebhookDelivery
+	err := db.Collection("webhook_deliveries").FindOneAndUpdate(ctx,
+		bson.M{"status": "pending", "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"nextAttemptAt": now.Add(webhookLease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1}).SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error fetching webhook deliveries: %v", err)
+		}
+		return false
+	}
+
+	var webhook Webhook
+	err = db.Collection("webhooks").FindOne(ctx, bson.M{"_id": delivery.WebhookID}).Decode(&webhook)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error fetching webhook %s: %v", delivery.WebhookID.Hex(), err)
+		return true
+	}
+	// Deliveries of deleted or deactivated webhooks fail without a request
+	gone := err == mongo.ErrNoDocuments || !webhook.Active
+	result := DeliveryAttempt{At: time.Now(), Error: "webhook was deleted or deactivated"}
+	if !gone {
+		result = postWebhook(webhook, delivery, []byte(delivery.Payload))
+	}
+
+	update := bson.M{"$push": bson.M{"attempts": result}}
+	attempts := len(delivery.Attempts) + 1
+	succeeded := result.Error == "" && result.StatusCode >= 200 && result.StatusCode < 300
+	switch {
+	case succeeded || gone || attempts >= webhookMaxAttempts:
+		status := "failed"
+		if succeeded {
+			status = "succeeded"
+		}
+		update["$set"] = bson.M{"status": status, "completedAt": time.Now()}
+		update["$unset"] = bson.M{"nextAttemptAt": ""}
+		if !succeeded {
+			log.Printf("Webhook %s gave up on %s event %s", delivery.WebhookID.Hex(), delivery.Event, delivery.EventID.Hex())
+		}
+	default:
+		update["$set"] = bson.M{"nextAttemptAt": time.Now().Add(webhookInitialBackoff << (attempts - 1))}
+	}
+
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer updateCancel()
+	if _, err := db.Collection("webhook_deliveries").UpdateOne(updateCtx, bson.M{"_id": delivery.ID}, update); err != nil {
+		log.Printf("Error updating webhook delivery: %v", err)
+	}
+	return true
+}
+
+func postWebhook(webhook Webhook, delivery WebhookDelivery, payload []byte) DeliveryAttempt {
+	attempt := DeliveryAttempt{At: time.Now()}
+	timestamp := strconv.FormatInt(attempt.At.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "work-tracking-system-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.Hex())
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(webhook.Secret, timestamp, payload))
+
+	resp, err := webhookClient.Do(req)
+	attempt.DurationMs = time.Since(attempt.At).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = resp.Status
+	}
+	return attempt
+}
+
+// internalIP reports addresses webhooks must not reach: loopback,
+// link-local (including cloud metadata endpoints), private and unspecified
+// addresses.
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// validateWebhookURL accepts absolute http(s) URLs whose host does not
+// resolve to an internal address.
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errInternalAddress
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(host); err != nil {
+			return fmt.Errorf("webhook host %q could not be resolved", host)
+		}
+	}
+	for _, ip := range ips {
+		if internalIP(ip) {
+			return errInternalAddress
+		}
+	}
+	return nil
+}
+
+func validateWebhook(webhook Webhook) error {
+	if err := validateWebhookURL(webhook.URL); err != nil {
+		return err
+	}
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range webhook.Events {
+		if !knownEvents[event] {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
+
+// createWebhook registers a subscription. A secret is generated when none is
+// given; it is only shown in this response.
+func createWebhook(c *fiber.Ctx) error {
+	var webhook Webhook
+	if err := c.BodyParser(&webhook); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz webhook ayarı: " + err.Error(),
+		})
+	}
+	if webhook.Secret == "" {
+		secret, err := newToken()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate secret: " + err.Error()})
+		}
+		webhook.Secret = secret
+	}
+	webhook.ID = primitive.NewObjectID()
+	webhook.Active = true
+	webhook.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Collection("webhooks").InsertOne(ctx, webhook); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create webhook: " + err.Error(),
+			"type":  "error",
+			"title": "Hata",
+			"text":  "Webhook eklenirken bir hata oluştu.",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"type":  "success",
+		"title": "Başarılı",
+		"text":  "Webhook başarıyla eklendi.",
+		"data":  webhook,
+	})
+}
+
+func getWebhooks(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("webhooks").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch webhooks: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []Webhook{}
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode webhooks: " + err.Error()})
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": webhooks,
+	})
+}
+
+func updateWebhook(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var update struct {
+		URL    *string   `json:"url"`
+		Secret *string   `json:"secret"`
+		Events *[]string `json:"events"`
+		Active *bool     `json:"active"`
+	}
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var webhook Webhook
+	if err := db.Collection("webhooks").FindOne(ctx, bson.M{"_id": id}).Decode(&webhook); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+	}
+	if update.URL != nil {
+		webhook.URL = *update.URL
+	}
+	if update.Secret != nil && *update.Secret != "" {
+		webhook.Secret = *update.Secret
+	}
+	if update.Events != nil {
+		webhook.Events = *update.Events
+	}
+	if update.Active != nil {
+		webhook.Active = *update.Active
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+			"type":  "warning",
+			"title": "Uyarı",
+			"text":  "Geçersiz webhook ayarı: " + err.Error(),
+		})
+	}
+
+	_, err = db.Collection("webhooks").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"url":    webhook.URL,
+		"secret": webhook.Secret,
+		"events": webhook.Events,
+		"active": webhook.Active,
+	}})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update webhook: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Webhook updated successfully"})
+}
+
+func deleteWebhook(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Collection("webhooks").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete webhook: " + err.Error()})
+	}
+	if result.DeletedCount == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+	}
+
+	if _, err := db.Collection("webhook_deliveries").DeleteMany(ctx, bson.M{"webhookId": id}); err != nil {
+		log.Printf("Error deleting webhook deliveries: %v", err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Webhook deleted successfully"})
+}
+
+// getWebhookDeliveries lists the latest deliveries of a webhook, optionally
+// filtered by status.
+func getWebhookDeliveries(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	filter := bson.M{"webhookId": id}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	limit := int64(c.QueryInt("limit", 100))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("webhook_deliveries").Find(ctx, filter,
+		options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch deliveries: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []WebhookDelivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode deliveries: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": deliveries,
+	})
+}
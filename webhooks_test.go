@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://93.184.216.34/hook", false},
+		{"http://93.184.216.34:8080/hook", false},
+		{"https://[2606:2800:220:1:248:1893:25c8:1946]/hook", false},
+		{"ftp://93.184.216.34/hook", true},
+		{"93.184.216.34/hook", true},
+		{"https:///hook", true},
+		{"http://localhost:8080/hook", true},
+		{"http://api.LOCALHOST/hook", true},
+		{"http://127.0.0.1/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://10.0.0.5/hook", true},
+		{"http://172.16.0.1/hook", true},
+		{"http://192.168.1.10/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[fe80::1]/hook", true},
+		{"http://[fd00::1]/hook", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validateWebhookURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhookURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	resp, err := webhookClient.Get("http://127.0.0.1:1/")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback address succeeded")
+	}
+	if !errors.Is(err, errInternalAddress) {
+		t.Errorf("got error %v, want %v", err, errInternalAddress)
+	}
+}
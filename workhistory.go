@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkEvent is one entry of a work's history, stored in "work_events". Each
+// entry keeps exactly what changed, so replaying the history in order gives
+// back the work document at any point in time.
+type WorkEvent struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	WorkID      primitive.ObjectID  `json:"workId" bson:"workId"`
+	ChangeID    string              `json:"-" bson:"changeId"` // Change stream event ID, makes redelivered changes a no-op
+	Type        string              `json:"type" bson:"type"`  // See workEventType
+	At          time.Time           `json:"at" bson:"at"`
+	ClusterTime primitive.Timestamp `json:"-" bson:"clusterTime"`                         // Orders entries written within the same second
+	Document    bson.M              `json:"document,omitempty" bson:"document,omitempty"` // Whole document for "created", "replaced" and "snapshot"
+	Set         bson.M              `json:"set,omitempty" bson:"set,omitempty"`
+	Unset       []string            `json:"unset,omitempty" bson:"unset,omitempty"`
+	Truncated   []TruncatedArray    `json:"truncated,omitempty" bson:"truncated,omitempty"`
+}
+
+// workEventType names a change after what it means for the work. A change
+// that fits several types (completing a video while adding its link) gets
+// the first matching one, its fields are kept in full either way.
+func workEventType(change ChangeEvent) string {
+	switch change.OperationType {
+	case "insert":
+		return "created"
+	case "replace":
+		return "replaced"
+	case "delete":
+		return "purged"
+	}
+
+	set := change.UpdateDescription.UpdatedFields
+	removed := map[string]bool{}
+	for _, field := range change.UpdateDescription.RemovedFields {
+		removed[field] = true
+	}
+	if _, ok := set[nameSyncMarker]; ok {
+		return "renamed"
+	}
+	_, reviewed := set["reviews"]
+	_, deleted := set["deletedAt"]
+	_, linkUpdated := set["videoLink"]
+	switch {
+	case set["status"] == "completed":
+		return "completed"
+	case set["status"] == "paused":
+		return "paused"
+	case set["status"] == "in_progress" && removed["pausedAt"]:
+		return "resumed"
+	case reviewed:
+		return "reviewed"
+	case set["isBeingReviewed"] == true:
+		return "revision_started"
+	case linkUpdated:
+		return "link_updated"
+	case deleted:
+		return "deleted"
+	case removed["deletedAt"]:
+		return "restored"
+	}
+	return "updated"
+}
+
+// recordWorkHistory is called for every change of the works collection.
+func recordWorkHistory(change ChangeEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	event := WorkEvent{
+		ID:          primitive.NewObjectID(),
+		WorkID:      change.DocumentKey.ID,
+		ChangeID:    change.ID.Lookup("_data").StringValue(),
+		Type:        workEventType(change),
+		At:          change.WallTime,
+		ClusterTime: change.ClusterTime,
+		Set:         change.UpdateDescription.UpdatedFields,
+		Unset:       change.UpdateDescription.RemovedFields,
+		Truncated:   change.UpdateDescription.TruncatedArrays,
+	}
+	if event.At.IsZero() {
+		event.At = time.Unix(int64(change.ClusterTime.T), 0)
+	}
+	if change.OperationType == "insert" || change.OperationType == "replace" {
+		if err := bson.Unmarshal(change.FullDocument, &event.Document); err != nil {
+			log.Printf("Error decoding work %s for history: %v", event.WorkID.Hex(), err)
+			return
+		}
+	}
+
+	if err := insertWorkEvent(ctx, event); err != nil {
+		log.Printf("Error recording history of work %s: %v", event.WorkID.Hex(), err)
+	}
+}
+
+// ensureWorkEventIndexes creates the unique index that drops redelivered
+// changes and the index used to read a work's history in order.
+func ensureWorkEventIndexes(ctx context.Context) error {
+	_, err := db.Collection("work_events").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "changeId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "workId", Value: 1}, {Key: "clusterTime", Value: 1}}},
+	})
+	return err
+}
+
+func insertWorkEvent(ctx context.Context, event WorkEvent) error {
+	_, err := db.Collection("work_events").InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// backfillWorkHistory gives works whose history does not start with a whole
+// document, because they existed before the history was kept, a "snapshot"
+// entry with their current document, so they can be rebuilt too. Running it
+// again does nothing for works that already have one.
+func backfillWorkHistory() error {
+	ctx := context.Background()
+	if err := ensureWorkEventIndexes(ctx); err != nil {
+		return err
+	}
+
+	// Works with only partial updates recorded still need a snapshot
+	known, err := db.Collection("work_events").Distinct(ctx, "workId",
+		bson.M{"type": bson.M{"$in": []string{"created", "replaced", "snapshot"}}})
+	if err != nil {
+		return err
+	}
+	cursor, err := db.Collection("works").Find(ctx, bson.M{"_id": bson.M{"$nin": known}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		id := doc["_id"].(primitive.ObjectID)
+		event := WorkEvent{
+			ID:       primitive.NewObjectID(),
+			WorkID:   id,
+			ChangeID: "snapshot:" + id.Hex(),
+			Type:     "snapshot",
+			At:       snapshotTime(doc),
+			Document: doc,
+		}
+		if err := insertWorkEvent(ctx, event); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("Backfilled history of %d works", count)
+	return nil
+}
+
+// snapshotTime dates a snapshot at the start of the work, or when its ID was
+// created if it has no start time. A snapshot has no cluster time, so it
+// also sorts before every recorded change.
+func snapshotTime(doc bson.M) time.Time {
+	if start, ok := doc["startTime"].(primitive.DateTime); ok && start != 0 {
+		return start.Time()
+	}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		return id.Timestamp()
+	}
+	return time.Time{}
+}
+
+func workHistory(ctx context.Context, workID primitive.ObjectID) ([]WorkEvent, error) {
+	cursor, err := db.Collection("work_events").Find(ctx, bson.M{"workId": workID},
+		options.Find().SetSort(bson.D{{Key: "clusterTime", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []WorkEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// replayWorkHistory applies the events up to and including at (all when at
+// is zero) and returns the resulting document, nil if the work did not exist
+// yet or was purged.
+func replayWorkHistory(events []WorkEvent, at time.Time) bson.M {
+	var doc bson.M
+	for _, event := range events {
+		if !at.IsZero() && event.At.After(at) {
+			continue
+		}
+		switch event.Type {
+		case "created", "replaced", "snapshot":
+			doc = copyDocument(event.Document)
+		case "purged":
+			doc = nil
+		default:
+			if doc == nil {
+				// History starts after the work was created
+				continue
+			}
+			// Copied so later events never change the values of earlier ones
+			for field, value := range copyDocument(event.Set) {
+				setDocumentPath(doc, strings.Split(field, "."), value)
+			}
+			for _, field := range event.Unset {
+				unsetDocumentPath(doc, strings.Split(field, "."))
+			}
+			for _, truncated := range event.Truncated {
+				truncateDocumentArray(doc, strings.Split(truncated.Field, "."), truncated.NewSize)
+			}
+		}
+	}
+	return doc
+}
+
+func copyDocument(doc bson.M) bson.M {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+	var copied bson.M
+	if err := bson.Unmarshal(data, &copied); err != nil {
+		return nil
+	}
+	return copied
+}
+
+// setDocumentPath sets a dotted update path such as "reviews.0.reviewerName"
+// the way MongoDB applies it, creating missing sub-documents.
+func setDocumentPath(container interface{}, path []string, value interface{}) interface{} {
+	switch node := container.(type) {
+	case bson.M:
+		if len(path) == 1 {
+			node[path[0]] = value
+		} else {
+			node[path[0]] = setDocumentPath(node[path[0]], path[1:], value)
+		}
+		return node
+	case primitive.A:
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			return node
+		}
+		for len(node) <= index {
+			node = append(node, nil)
+		}
+		if len(path) == 1 {
+			node[index] = value
+		} else {
+			node[index] = setDocumentPath(node[index], path[1:], value)
+		}
+		return node
+	}
+	return setDocumentPath(bson.M{}, path, value)
+}
+
+func unsetDocumentPath(container interface{}, path []string) {
+	switch node := container.(type) {
+	case bson.M:
+		if len(path) == 1 {
+			delete(node, path[0])
+		} else {
+			unsetDocumentPath(node[path[0]], path[1:])
+		}
+	case primitive.A:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index >= len(node) {
+			return
+		}
+		if len(path) == 1 {
+			// $unset leaves a null in arrays
+			node[index] = nil
+		} else {
+			unsetDocumentPath(node[index], path[1:])
+		}
+	}
+}
+
+func truncateDocumentArray(doc bson.M, path []string, size int) {
+	parent := doc
+	for _, field := range path[:len(path)-1] {
+		next, ok := parent[field].(bson.M)
+		if !ok {
+			return
+		}
+		parent = next
+	}
+	field := path[len(path)-1]
+	if array, ok := parent[field].(primitive.A); ok && size < len(array) {
+		parent[field] = array[:size]
+	}
+}
+
+func decodeWorkDocument(doc bson.M) (Work, error) {
+	var work Work
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return work, err
+	}
+	err = bson.Unmarshal(data, &work)
+	return work, err
+}
+
+// getWorkHistory lists the history of a work, oldest first.
+func getWorkHistory(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := workHistory(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work history: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": events,
+	})
+}
+
+// getRebuiltWork rebuilds a work from its history, as of the RFC 3339 time
+// in "at" or with the full history. It also lists the fields in which the
+// stored document differs from the full history.
+func getRebuiltWork(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var at time.Time
+	if value := c.Query("at"); value != "" {
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid time format, use RFC 3339"})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := workHistory(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work history: " + err.Error()})
+	}
+	if len(events) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Work has no history"})
+	}
+	doc := replayWorkHistory(events, at)
+	if doc == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Work did not exist at that time"})
+	}
+	work, err := decodeWorkDocument(doc)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode rebuilt work: " + err.Error()})
+	}
+
+	var stored bson.M
+	err = db.Collection("works").FindOne(ctx, bson.M{"_id": id}).Decode(&stored)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": fiber.Map{
+			"work":        work,
+			"events":      len(events),
+			"differences": documentDifferences(stored, replayWorkHistory(events, time.Time{})),
+		},
+	})
+}
+
+// documentDifferences lists the top-level fields that differ between two
+// documents.
+func documentDifferences(stored, rebuilt bson.M) []string {
+	fields := map[string]bool{}
+	for field := range stored {
+		fields[field] = true
+	}
+	for field := range rebuilt {
+		fields[field] = true
+	}
+	differences := []string{}
+	for field := range fields {
+		if !reflect.DeepEqual(stored[field], rebuilt[field]) {
+			differences = append(differences, field)
+		}
+	}
+	sort.Strings(differences)
+	return differences
+}
+
+// rebuildWorks replaces works with the document rebuilt from their history,
+// which recomputes every statistic derived from them. Limited to one
+// employee with employeeId; dryRun=true only reports the works that differ.
+func rebuildWorks(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dryRun")
+	filter := bson.M{}
+	if employeeID := c.Query("employeeId"); employeeID != "" {
+		id, err := primitive.ObjectIDFromHex(employeeID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID format"})
+		}
+		filter["employeeId"] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("works").Find(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch works: " + err.Error()})
+	}
+	defer cursor.Close(ctx)
+
+	type rebuiltWork struct {
+		WorkID      primitive.ObjectID `json:"workId"`
+		Differences []string           `json:"differences"`
+	}
+	rebuilt := []rebuiltWork{}
+	withoutHistory := 0
+	for cursor.Next(ctx) {
+		var stored bson.M
+		if err := cursor.Decode(&stored); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode work: " + err.Error()})
+		}
+		id := stored["_id"].(primitive.ObjectID)
+		events, err := workHistory(ctx, id)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work history: " + err.Error()})
+		}
+		doc := replayWorkHistory(events, time.Time{})
+		if doc == nil {
+			withoutHistory++
+			continue
+		}
+		differences := documentDifferences(stored, doc)
+		if len(differences) == 0 {
+			continue
+		}
+		if !dryRun {
+			if _, err := db.Collection("works").ReplaceOne(ctx, bson.M{"_id": id}, doc); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("Failed to rebuild work %s: %v", id.Hex(), err)})
+			}
+		}
+		rebuilt = append(rebuilt, rebuiltWork{WorkID: id, Differences: differences})
+	}
+	if err := cursor.Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read works: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"type": "success",
+		"data": fiber.Map{
+			"dryRun":         dryRun,
+			"rebuilt":        rebuilt,
+			"withoutHistory": withoutHistory,
+		},
+	})
+}
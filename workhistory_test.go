@@ -0,0 +1,187 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReplayWorkHistory(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []WorkEvent{
+		{Type: "created", At: at(0), Document: bson.M{"status": "in_progress", "description": "Kurgu", "reviews": primitive.A{}}},
+		{Type: "paused", At: at(10), Set: bson.M{"status": "paused", "pausedAt": "10:10"}},
+		{Type: "resumed", At: at(20), Set: bson.M{"status": "in_progress"}, Unset: []string{"pausedAt"}},
+		{Type: "reviewed", At: at(30), Set: bson.M{"reviews.0": bson.M{"reviewerName": "Ali", "comment": "İyi"}}},
+		{Type: "renamed", At: at(40), Set: bson.M{"reviews.0.reviewerName": "Ali Veli"}},
+		{Type: "completed", At: at(50), Set: bson.M{"status": "completed", "durationMinutes": int32(40)}},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bson.M
+	}{
+		{"before creation", start.Add(-time.Minute), nil},
+		{"just created", at(0), bson.M{"status": "in_progress", "description": "Kurgu", "reviews": primitive.A{}}},
+		{"paused", at(15), bson.M{"status": "paused", "pausedAt": "10:10", "description": "Kurgu", "reviews": primitive.A{}}},
+		{"resumed", at(25), bson.M{"status": "in_progress", "description": "Kurgu", "reviews": primitive.A{}}},
+		{"full history", time.Time{}, bson.M{
+			"status":          "completed",
+			"description":     "Kurgu",
+			"durationMinutes": int32(40),
+			"reviews":         primitive.A{bson.M{"reviewerName": "Ali Veli", "comment": "İyi"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replayWorkHistory(events, tt.at); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replayWorkHistory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Replaying must not change the recorded events
+	if got := events[3].Set["reviews.0"].(bson.M)["reviewerName"]; got != "Ali" {
+		t.Errorf("replay changed a recorded event, reviewerName = %v", got)
+	}
+}
+
+func TestReplayWorkHistoryBaseDocuments(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		events []WorkEvent
+		want   bson.M
+	}{
+		{
+			"updates before any base document are skipped",
+			[]WorkEvent{
+				{Type: "updated", At: start, Set: bson.M{"description": "Eski"}},
+				{Type: "snapshot", At: start.Add(time.Minute), Document: bson.M{"description": "Anlık"}},
+				{Type: "updated", At: start.Add(2 * time.Minute), Set: bson.M{"videoLink": "https://example.com/v"}},
+			},
+			bson.M{"description": "Anlık", "videoLink": "https://example.com/v"},
+		},
+		{
+			"replace starts over",
+			[]WorkEvent{
+				{Type: "created", At: start, Document: bson.M{"description": "İlk", "status": "in_progress"}},
+				{Type: "replaced", At: start.Add(time.Minute), Document: bson.M{"description": "Yeni"}},
+			},
+			bson.M{"description": "Yeni"},
+		},
+		{
+			"purged work is gone",
+			[]WorkEvent{
+				{Type: "created", At: start, Document: bson.M{"description": "İlk"}},
+				{Type: "purged", At: start.Add(time.Minute)},
+			},
+			nil,
+		},
+		{
+			"truncated arrays",
+			[]WorkEvent{
+				{Type: "created", At: start, Document: bson.M{"reviews": primitive.A{"a", "b", "c"}}},
+				{Type: "updated", At: start.Add(time.Minute), Truncated: []TruncatedArray{{Field: "reviews", NewSize: 1}}},
+			},
+			bson.M{"reviews": primitive.A{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replayWorkHistory(tt.events, time.Time{}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replayWorkHistory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDocumentPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   bson.M
+		path  string
+		value interface{}
+		want  bson.M
+	}{
+		{"top level", bson.M{"a": 1}, "b", 2, bson.M{"a": 1, "b": 2}},
+		{"missing sub-document", bson.M{}, "manualEntry.decidedBy", "admin", bson.M{"manualEntry": bson.M{"decidedBy": "admin"}}},
+		{"array element field", bson.M{"reviews": primitive.A{bson.M{"n": "Ali"}}}, "reviews.0.n", "Veli", bson.M{"reviews": primitive.A{bson.M{"n": "Veli"}}}},
+		{"array grows with nulls", bson.M{"reviews": primitive.A{}}, "reviews.2", "c", bson.M{"reviews": primitive.A{nil, nil, "c"}}},
+		{"non-numeric array index is ignored", bson.M{"reviews": primitive.A{"a"}}, "reviews.x", "b", bson.M{"reviews": primitive.A{"a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDocumentPath(tt.doc, strings.Split(tt.path, "."), tt.value)
+			if !reflect.DeepEqual(tt.doc, tt.want) {
+				t.Errorf("setDocumentPath(%q) = %v, want %v", tt.path, tt.doc, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnsetDocumentPath(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  bson.M
+		path string
+		want bson.M
+	}{
+		{"top level", bson.M{"a": 1, "b": 2}, "b", bson.M{"a": 1}},
+		{"nested", bson.M{"m": bson.M{"x": 1, "y": 2}}, "m.x", bson.M{"m": bson.M{"y": 2}}},
+		{"array element becomes null", bson.M{"l": primitive.A{"a", "b"}}, "l.0", bson.M{"l": primitive.A{nil, "b"}}},
+		{"missing path", bson.M{"a": 1}, "m.x", bson.M{"a": 1}},
+		{"index out of range", bson.M{"l": primitive.A{"a"}}, "l.5", bson.M{"l": primitive.A{"a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetDocumentPath(tt.doc, strings.Split(tt.path, "."))
+			if !reflect.DeepEqual(tt.doc, tt.want) {
+				t.Errorf("unsetDocumentPath(%q) = %v, want %v", tt.path, tt.doc, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotTime(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 30, 0, 0, time.UTC)
+	id := primitive.NewObjectIDFromTimestamp(start.Add(-time.Hour))
+
+	tests := []struct {
+		name string
+		doc  bson.M
+		want time.Time
+	}{
+		{"start time", bson.M{"_id": id, "startTime": primitive.NewDateTimeFromTime(start)}, start},
+		{"ID timestamp without a start time", bson.M{"_id": id}, start.Add(-time.Hour)},
+		{"nothing to go by", bson.M{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshotTime(tt.doc); !got.Equal(tt.want) {
+				t.Errorf("snapshotTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentDifferences(t *testing.T) {
+	stored := bson.M{"status": "completed", "durationMinutes": int32(40), "description": "Kurgu"}
+	rebuilt := bson.M{"status": "completed", "durationMinutes": int32(35), "videoLink": "https://example.com/v"}
+
+	want := []string{"description", "durationMinutes", "videoLink"}
+	if got := documentDifferences(stored, rebuilt); !reflect.DeepEqual(got, want) {
+		t.Errorf("documentDifferences() = %v, want %v", got, want)
+	}
+	if got := documentDifferences(stored, stored); len(got) != 0 {
+		t.Errorf("documentDifferences() of equal documents = %v, want none", got)
+	}
+}